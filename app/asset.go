@@ -0,0 +1,284 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mcfriend99/gaga/logger"
+)
+
+// default asset settings.
+const (
+	defaultAssetManifest = "data/assets.json"
+	assetHashLength      = 10
+	assetCacheControl    = "public, max-age=31536000, immutable"
+)
+
+// assetManifestFile is the content of the asset manifest file. Build
+// is set when the manifest was written by the build step.
+type assetManifestFile struct {
+	Build  bool              `json:"build"`
+	Assets map[string]string `json:"assets"`
+}
+
+// assetStamp is the hash of a file as of its last change.
+type assetStamp struct {
+	modTime time.Time
+	size    int64
+	hash    string
+}
+
+// assetManifest maps the URLs of static files to their fingerprinted
+// URLs and back.
+type assetManifest struct {
+	_fingerprinted map[string]string
+	_originals     map[string]string
+	_stamps        map[string]assetStamp
+	_lock          sync.Mutex
+}
+
+func _newAssetManifest(fingerprinted map[string]string) *assetManifest {
+	m := &assetManifest{
+		_fingerprinted: fingerprinted,
+		_originals:     make(map[string]string, len(fingerprinted)),
+		_stamps:        make(map[string]assetStamp),
+	}
+	for original, url := range fingerprinted {
+		m._originals[url] = original
+	}
+	return m
+}
+
+// assets returns the asset manifest of the app. When fingerprinting is
+// on, the files of the Static routes are hashed on startup unless the
+// manifest was written by the build step, in which case it is loaded.
+func (g *Gaga) assets() *assetManifest {
+	g._assetsOnce.Do(func() {
+		g._assets = _newAssetManifest(make(map[string]string))
+		if !g.Config.Assets.Fingerprint {
+			return
+		}
+
+		if !g.Config.Dev {
+			if manifest, err := g.loadAssets(); err == nil && manifest.Build {
+				g._assets = _newAssetManifest(manifest.Assets)
+				return
+			}
+		}
+
+		if err := g.buildAssets(false); err != nil {
+			logger.Error("Failed to fingerprint assets:", err)
+			return
+		}
+		logger.Infof("Fingerprinted %d assets into %s", len(g._assets._fingerprinted), g.manifestFile())
+	})
+	return g._assets
+}
+
+// manifestFile returns the file the asset manifest is kept in.
+func (g *Gaga) manifestFile() string {
+	if g.Config.Assets.Manifest != "" {
+		return g.Config.Assets.Manifest
+	}
+	return defaultAssetManifest
+}
+
+// loadAssets reads the asset manifest from its file.
+func (g *Gaga) loadAssets() (*assetManifestFile, error) {
+	b, err := ioutil.ReadFile(g.manifestFile())
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest assetManifestFile
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Assets == nil {
+		manifest.Assets = make(map[string]string)
+	}
+	return &manifest, nil
+}
+
+// BuildAssets fingerprints every file served by a Static route with a
+// hash of its content and writes the asset manifest, mapping the URL of
+// every file to its fingerprinted URL.
+// Files are hashed on every startup when fingerprinting is on. Running
+// it as a build step skips that, so the files must not change after.
+func (g *Gaga) BuildAssets() error {
+	return g.buildAssets(true)
+}
+
+// buildAssets fingerprints the files of the Static routes and writes the
+// asset manifest, marked as written by the build step if build is set.
+func (g *Gaga) buildAssets(build bool) error {
+	g._routesOnce.Do(g.buildRoutes)
+
+	fingerprinted := make(map[string]string)
+	for _, route := range g._router._static {
+		err := filepath.Walk(route._dir, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if strings.HasPrefix(info.Name(), ".") {
+				if info.IsDir() && file != route._dir {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.IsDir() {
+				return nil
+			}
+
+			rel, err := filepath.Rel(route._dir, file)
+			if err != nil {
+				return err
+			}
+			hash, err := hashFile(file)
+			if err != nil {
+				return err
+			}
+
+			url := joinPath(route.Path, filepath.ToSlash(rel))
+			if _, ok := fingerprinted[url]; !ok {
+				fingerprinted[url] = fingerprint(url, hash)
+			}
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	b, err := json.MarshalIndent(assetManifestFile{Build: build, Assets: fingerprinted}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(g.manifestFile()), 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(g.manifestFile(), b, 0644); err != nil {
+		return err
+	}
+
+	g._assets = _newAssetManifest(fingerprinted)
+	return nil
+}
+
+// hashFile returns the start of the hex encoded SHA-256 hash of the
+// content of the file.
+func hashFile(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil))[:assetHashLength], nil
+}
+
+// fingerprint adds the hash to the name of the file at the URL, right
+// before its extension.
+//
+//  Example:
+//
+//  fingerprint("/static/app.css", "3f9a1c2b4d") // => /static/app.3f9a1c2b4d.css
+func fingerprint(url string, hash string) string {
+	dir, name := path.Split(url)
+	ext := path.Ext(name)
+	if ext == name {
+		ext = ""
+	}
+	return dir + strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+// Asset returns the URL of a file served by a Static route,
+// fingerprinted when fingerprinting is on. Paths not starting with a
+// slash are relative to the directories of the Static routes, the first
+// route whose directory holds the file is used. Other URLs are only
+// fingerprinted when they are in the asset manifest.
+//
+//  Example:
+//
+//  // r.Static("/static/", "./static")
+//  g.Asset("app.css")         // => /static/app.3f9a1c2b4d.css
+//  g.Asset("/static/app.css") // => /static/app.3f9a1c2b4d.css
+func (g *Gaga) Asset(name string) string {
+	url := g.assetURL(name)
+	if fingerprinted, ok := g.assets()._fingerprinted[url]; ok {
+		return fingerprinted
+	}
+	return url
+}
+
+// Asset returns the URL of a file served by a Static route.
+// See Gaga.Asset.
+func (r *Request) Asset(name string) string {
+	return r._app.Asset(name)
+}
+
+// assetURL returns the URL a Static route serves the file at.
+func (g *Gaga) assetURL(name string) string {
+	if strings.HasPrefix(name, "/") || strings.Contains(name, "://") {
+		return name
+	}
+
+	g._routesOnce.Do(g.buildRoutes)
+	for _, route := range g._router._static {
+		file := filepath.Join(route._dir, filepath.FromSlash(name))
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return joinPath(route.Path, name)
+		}
+	}
+	return "/" + name
+}
+
+// original returns the URL of the file a fingerprinted URL stands for
+// and whether the URL is fingerprinted.
+func (m *assetManifest) original(url string) (string, bool) {
+	original, ok := m._originals[url]
+	return original, ok
+}
+
+// current reports whether the file still has the hash the fingerprinted
+// URL carries. Hashes are kept until the file changes.
+func (m *assetManifest) current(url string, file string) bool {
+	original, ok := m._originals[url]
+	if !ok {
+		return false
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return false
+	}
+
+	m._lock.Lock()
+	stamp, ok := m._stamps[file]
+	m._lock.Unlock()
+	if !ok || !stamp.modTime.Equal(info.ModTime()) || stamp.size != info.Size() {
+		hash, err := hashFile(file)
+		if err != nil {
+			return false
+		}
+		stamp = assetStamp{modTime: info.ModTime(), size: info.Size(), hash: hash}
+
+		m._lock.Lock()
+		m._stamps[file] = stamp
+		m._lock.Unlock()
+	}
+	return fingerprint(original, stamp.hash) == url
+}
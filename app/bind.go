@@ -0,0 +1,239 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bind fills the struct dst points to from the request and validates it.
+//
+// JSON bodies are decoded into dst first using its json tags. Fields with
+// a form tag are then filled from the posted form falling back to the
+// query string, fields with a query tag from the query string and fields
+// with a param tag from the route params. Strings, bools, numbers, times
+// and slices of them are converted as needed.
+//
+// Fields of nested structs are filled from keys in the bracket form read
+// by PostMap and GetMap, such as address[city] for the city field of an
+// address field. Fields of embedded structs are filled as fields of dst.
+//
+// Conversion and validation failures are returned as ValidationErrors.
+//
+//  Example:
+//
+//  type UserForm struct {
+//  	ID    int      `param:"id"`
+//  	Email string   `form:"email" json:"email" validate:"required,email"`
+//  	Tags  []string `form:"tags" validate:"max=5"`
+//  }
+//
+//  var form UserForm
+//  if err := r.Bind(&form); err != nil {
+//  	r.Response.StatusCode = http.StatusUnprocessableEntity
+//  	return r.JSON(err)
+//  }
+func (r *Request) Bind(dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot bind into %T, it is not a pointer to a struct", dst)
+	}
+
+	if status := r.parseBody(); status != 0 {
+		return fmt.Errorf("could not read the request body")
+	}
+
+	contentType, _, _ := mime.ParseMediaType(r.BaseRequest.Header.Get("Content-Type"))
+	if isJSONType(contentType) && len(r._body) > 0 {
+		if err := json.Unmarshal(r._body, dst); err != nil {
+			return err
+		}
+	}
+
+	errs := ValidationErrors{}
+	r.bindStruct(v.Elem(), nil, errs)
+
+	// fields that could not be converted are not validated.
+	invalid := ValidationErrors{}
+	validateStruct(v.Elem(), "", invalid)
+	for field, messages := range invalid {
+		if _, ok := errs[field]; !ok {
+			errs[field] = messages
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// isJSONType reports whether the media type is a JSON one.
+func isJSONType(contentType string) bool {
+	return contentType == "application/json" || strings.HasSuffix(contentType, "+json")
+}
+
+// bindValues returns the values of the request for the binding tag.
+func (r *Request) bindValues(tag string, name string) ([]string, bool) {
+	switch tag {
+	case "form":
+		if values := allValues(r._postsData, name); len(values) > 0 {
+			return values, true
+		}
+		values := r.GetAll(name)
+		return values, len(values) > 0
+	case "query":
+		values := r.GetAll(name)
+		return values, len(values) > 0
+	case "param":
+		value, ok := r.Params[name]
+		return []string{value}, ok
+	}
+	return nil, false
+}
+
+// bindStruct fills the fields of the struct, nested in the structs
+// named by path.
+func (r *Request) bindStruct(v reflect.Value, path []string, errs ValidationErrors) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		// embedded and nested structs bind their own fields.
+		inner := reflect.Indirect(v.Field(i))
+		if inner.Kind() == reflect.Struct && inner.Type() != timeType {
+			if field.Anonymous {
+				r.bindStruct(inner, path, errs)
+			} else {
+				r.bindStruct(inner, append(path[:len(path):len(path)], fieldName(field)), errs)
+			}
+			continue
+		}
+
+		for _, tag := range []string{"form", "query", "param"} {
+			name := field.Tag.Get(tag)
+			if name == "" || name == "-" {
+				continue
+			}
+
+			// route params are never nested.
+			if tag != "param" {
+				name = bracketKey(path, name)
+			}
+
+			values, ok := r.bindValues(tag, name)
+			if !ok || len(values) == 0 {
+				continue
+			}
+			if err := setField(v.Field(i), values, field.Tag.Get("time_format")); err != nil {
+				errs.Add(strings.Join(append(path[:len(path):len(path)], fieldName(field)), "."), err.Error())
+			}
+		}
+	}
+}
+
+// bracketKey returns the key of the field name nested in the path.
+//
+//  Example:
+//
+//  bracketKey([]string{"user", "address"}, "city") // => user[address][city]
+func bracketKey(path []string, name string) string {
+	if len(path) == 0 {
+		return name
+	}
+	return path[0] + "[" + strings.Join(append(path[1:len(path):len(path)], name), "][") + "]"
+}
+
+// time layouts tried when binding times without a time_format tag.
+var bindTimeLayouts = []string{time.RFC3339, "2006-01-02T15:04", dateLayout}
+
+// setField converts the values to the type of the field and sets it.
+// Only slices take more than the first value.
+func setField(field reflect.Value, values []string, timeFormat string) error {
+	switch field.Kind() {
+	case reflect.Ptr:
+		if values[0] == "" {
+			return nil
+		}
+		value := reflect.New(field.Type().Elem())
+		if err := setField(value.Elem(), values, timeFormat); err != nil {
+			return err
+		}
+		field.Set(value)
+		return nil
+	case reflect.Slice:
+		if field.Type().Elem().Kind() == reflect.Uint8 {
+			field.SetBytes([]byte(values[0]))
+			return nil
+		}
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setField(slice.Index(i), []string{value}, timeFormat); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+
+	value := values[0]
+	if field.Kind() != reflect.String && value == "" {
+		return nil
+	}
+
+	if field.Type() == timeType {
+		layouts := bindTimeLayouts
+		if timeFormat != "" {
+			layouts = []string{timeFormat}
+		}
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				field.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return fmt.Errorf("must be a valid time")
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			if value != "on" {
+				return fmt.Errorf("must be true or false")
+			}
+			b = true
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a positive integer")
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a number")
+		}
+		field.SetFloat(n)
+	default:
+		return fmt.Errorf("cannot be bound")
+	}
+	return nil
+}
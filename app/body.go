@@ -0,0 +1,155 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+
+	"github.com/mcfriend99/gaga/logger"
+)
+
+// default limits of request bodies.
+const (
+	defaultMaxBodySize        = 32 << 20
+	defaultMaxMultipartMemory = 8 << 20
+)
+
+// errBodyTooLarge is returned when reading past the size limit of a
+// request body.
+var errBodyTooLarge = errors.New("request body too large")
+
+// limitedBody is a request body that fails once more than the limit is
+// read from it, recording that it did.
+type limitedBody struct {
+	io.ReadCloser
+	_remaining int64
+	_exceeded  bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b._exceeded {
+		return 0, errBodyTooLarge
+	}
+
+	// one byte past the limit is enough to tell the body is too large.
+	if int64(len(p)) > b._remaining+1 {
+		p = p[:b._remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) <= b._remaining {
+		b._remaining -= int64(n)
+		return n, err
+	}
+
+	n = int(b._remaining)
+	b._remaining = 0
+	b._exceeded = true
+	return n, errBodyTooLarge
+}
+
+// parseBody reads the request body once, picking the parser from its
+// Content-Type. Url encoded and multipart forms fill the post and file
+// data and JSON objects fill the JSON data with their top level keys.
+// Any other body is left unread, behind the size limit, for Body or
+// for controllers streaming BaseRequest.Body.
+//
+// It returns the status code to respond with when the body cannot be
+// read or 0 if all went well.
+func (r *Request) parseBody() int {
+	if r._bodyParsed {
+		return r._bodyStatus
+	}
+	r._bodyParsed = true
+
+	req := r.BaseRequest
+	if req.Body == nil || req.Body == http.NoBody {
+		r._bodyRead = true
+		return 0
+	}
+
+	maxSize := int64(defaultMaxBodySize)
+	maxMemory := int64(defaultMaxMultipartMemory)
+	if r._app != nil && r._app.Config.Server.MaxBodySize > 0 {
+		maxSize = r._app.Config.Server.MaxBodySize
+	}
+	if r._app != nil && r._app.Config.Server.MaxMultipartMemory > 0 {
+		maxMemory = r._app.Config.Server.MaxMultipartMemory
+	}
+
+	if req.ContentLength > maxSize {
+		r._bodyStatus = http.StatusRequestEntityTooLarge
+		return r._bodyStatus
+	}
+	// forms read from a plain body are capped at 10MB by ParseForm, one
+	// wrapped by MaxBytesReader is left to its limit, which the limited
+	// body reaches first.
+	body := &limitedBody{ReadCloser: req.Body, _remaining: maxSize}
+	req.Body = http.MaxBytesReader(r.Writer, body, maxSize+1)
+
+	contentType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if contentType != "application/x-www-form-urlencoded" && contentType != "multipart/form-data" &&
+		!isJSONType(contentType) {
+		return 0
+	}
+	r._bodyRead = true
+
+	var err error
+	switch {
+	case contentType == "application/x-www-form-urlencoded":
+		if err = req.ParseForm(); err == nil {
+			r._postsData = req.PostForm
+		}
+	case contentType == "multipart/form-data":
+		if err = req.ParseMultipartForm(maxMemory); err == nil {
+			form := req.MultipartForm
+			r.AfterResponse(func() {
+				form.RemoveAll()
+			})
+
+			r._postsData = form.Value
+			for s, files := range form.File {
+				r._filesData[s] = files
+			}
+		}
+	case isJSONType(contentType):
+		if r._body, err = ioutil.ReadAll(req.Body); err == nil && len(r._body) > 0 {
+			var data interface{}
+			if err = json.Unmarshal(r._body, &data); err == nil {
+				if object, ok := data.(map[string]interface{}); ok {
+					r._jsonData = object
+				}
+			}
+		}
+	}
+
+	if body._exceeded {
+		// the rest of the body is never read so the connection can not
+		// be used again.
+		r.Response.Header.Set("Connection", "close")
+		r._bodyStatus = http.StatusRequestEntityTooLarge
+	} else if err != nil {
+		r._bodyStatus = http.StatusBadRequest
+	}
+	return r._bodyStatus
+}
+
+// Body returns the raw body of JSON and other non form requests. Bodies
+// other than JSON are only read when it is first called, it returns nil
+// when they can not be read or are larger than the size limit.
+func (r *Request) Body() []byte {
+	if r.parseBody() != 0 || r._bodyRead {
+		return r._body
+	}
+	r._bodyRead = true
+
+	body, err := ioutil.ReadAll(r.BaseRequest.Body)
+	if err != nil {
+		logger.Warn("Failed to read request body:", err)
+		return nil
+	}
+	r._body = body
+	return r._body
+}
@@ -14,6 +14,36 @@ type ServerConfig struct {
 	Secure             bool   `json:"secure,omitempty"`
 	TLSCertificateFile string `json:"tls_certificate_file,omitempty"`
 	TLSKeyFile         string `json:"tls_key_file,omitempty"`
+
+	// CleanPath decides what happens to requests for paths with
+	// duplicate slashes or dot segments such as /a//b/../c.
+	//
+	// Options include:
+	//  match, redirect
+	//
+	// match (the default) serves the route of the cleaned path while
+	// redirect permanently redirects to the cleaned path.
+	CleanPath string `json:"clean_path,omitempty"`
+
+	// TrailingSlash decides what happens to requests for paths with a
+	// trailing slash such as /users/.
+	//
+	// Options include:
+	//  match, redirect
+	//
+	// match (the default) serves the route of the path without the slash
+	// while redirect permanently redirects to it. Static routes always
+	// match as they serve directories at paths with a trailing slash.
+	TrailingSlash string `json:"trailing_slash,omitempty"`
+
+	// MaxBodySize is the size in bytes of the largest request body
+	// accepted. Larger bodies get a 413 response. Defaults to 32MB.
+	MaxBodySize int64 `json:"max_body_size,omitempty"`
+
+	// MaxMultipartMemory is how many bytes of a multipart body are kept
+	// in memory. The rest of the files go to temporary files on disk.
+	// Defaults to 8MB.
+	MaxMultipartMemory int64 `json:"max_multipart_memory,omitempty"`
 }
 
 // DatabaseConfig configuration struct
@@ -65,13 +95,107 @@ type SEOConfig struct {
 	CompressionThreshold int `json:"compression_threshold,omitempty"`
 }
 
+// AppConfig configuration struct
+type AppConfig struct {
+	// Secret is the key used to sign cookies such as the session cookie.
+	// It should be long and random and kept out of version control.
+	//
+	// NOTE:
+	//
+	//  When empty, a random secret is generated on startup and
+	//  cookies signed with it stop working once the app restarts.
+	//  The file and cookie session stores refuse to start without it.
+	Secret string `json:"secret,omitempty"`
+
+	// PreviousSecrets are secrets that were used before the current one.
+	// Cookies signed or encrypted with them are still accepted so the
+	// secret can be rotated without logging everyone out.
+	PreviousSecrets []string `json:"previous_secrets,omitempty"`
+}
+
+// SessionConfig configuration struct
+type SessionConfig struct {
+	// Store is where session data is kept.
+	//
+	// Options include:
+	//  memory, file, cookie, none
+	//
+	// memory (the default) keeps sessions until the app restarts, file
+	// keeps them in Path, cookie keeps them encrypted in the session
+	// cookie itself and none keeps no sessions at all.
+	//
+	// NOTE:
+	//
+	//  Browsers limit cookies to about 4KB so the cookie store only
+	//  suits small sessions.
+	Store string `json:"store,omitempty"`
+
+	// Path is the directory of the file store. Defaults to data/sessions.
+	Path string `json:"path,omitempty"`
+
+	// CookieName is the name of the session cookie.
+	// Defaults to gaga_session.
+	CookieName string `json:"cookie_name,omitempty"`
+
+	// IdleTimeout is how many seconds a session lasts without being
+	// used. Defaults to 2 hours.
+	IdleTimeout int `json:"idle_timeout,omitempty"`
+
+	// Lifetime is how many seconds a session lasts at most, however
+	// often it is used. Defaults to 24 hours.
+	Lifetime int `json:"lifetime,omitempty"`
+
+	// GCInterval is how many seconds pass between removals of expired
+	// sessions from the store. Defaults to 10 minutes.
+	GCInterval int `json:"gc_interval,omitempty"`
+
+	// Secure limits the session cookie to HTTPS requests.
+	Secure bool `json:"secure,omitempty"`
+
+	// SameSite is the SameSite attribute of the session cookie.
+	//
+	// Options include:
+	//  lax, strict, none
+	SameSite string `json:"same_site,omitempty"`
+}
+
+// ViewConfig configuration struct
+type ViewConfig struct {
+	// Path is the directory views are loaded from. Defaults to views.
+	Path string `json:"path,omitempty"`
+
+	// Extension is the file extension of views, left out of their
+	// names. Defaults to .html.
+	Extension string `json:"extension,omitempty"`
+}
+
+// AssetConfig configuration struct
+type AssetConfig struct {
+	// Fingerprint adds a hash of their content to the URLs of files
+	// served by Static routes, so they can be cached for good.
+	Fingerprint bool `json:"fingerprint,omitempty"`
+
+	// Manifest is the file mapping the URLs of the files to their
+	// fingerprinted URLs. Defaults to data/assets.json.
+	Manifest string `json:"manifest,omitempty"`
+}
+
 // Config is the main configuration struct
+//
+// Dev is set when the app runs with a config.dev.json file or when
+// the configuration asks for it. In dev mode views are reloaded when
+// their files change and errors in views are shown in the browser.
 type Config struct {
-	Server   ServerConfig `json:"server"`
-	Database interface{}  `json:"database,omitempty"`
-	Log      LogConfig    `json:"log,omitempty"`
-	SEO      SEOConfig    `json:"seo,omitempty"`
-	Custom   interface{}  `json:"custom,omitempty"`
+	Dev      bool          `json:"dev,omitempty"`
+	App      AppConfig     `json:"app,omitempty"`
+	Server   ServerConfig  `json:"server"`
+	Database interface{}   `json:"database,omitempty"`
+	Log      LogConfig     `json:"log,omitempty"`
+	Session  SessionConfig `json:"session,omitempty"`
+	Views    ViewConfig    `json:"views,omitempty"`
+	Assets   AssetConfig   `json:"assets,omitempty"`
+	SEO      SEOConfig     `json:"seo,omitempty"`
+	Custom   interface{}   `json:"custom,omitempty"`
 }
 
 // LoadConfig loads Gaga configurations from the specified file.
@@ -88,7 +212,7 @@ func LoadConfig() *Config {
 		priority = []string{"dev", ""}
 	}
 
-	matchFound := false
+	matchFound, devFound := false, false
 	for _, p := range priority {
 		var n string
 		if p != "" {
@@ -99,6 +223,7 @@ func LoadConfig() *Config {
 
 		if bytes, err := ioutil.ReadFile(n); err == nil {
 			matchFound = true
+			devFound = devFound || p == "dev"
 			if err = json.Unmarshal(bytes, &config); err != nil {
 				log.Fatalln("Could not decode configuration file.")
 			}
@@ -110,5 +235,9 @@ func LoadConfig() *Config {
 			"Try renaming config.sample.json to config.json")
 	}
 
+	if devFound {
+		config.Dev = true
+	}
+
 	return &config
 }
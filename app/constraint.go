@@ -0,0 +1,83 @@
+package app
+
+import (
+	"strconv"
+	"time"
+)
+
+// builtinConstraints are the param types that can be used inline in
+// route paths without being registered, e.g. /users/{id:int}.
+var builtinConstraints = map[string]func(string) bool{
+	"int": func(s string) bool {
+		_, err := strconv.ParseInt(s, 10, 64)
+		return err == nil
+	},
+	"float": func(s string) bool {
+		_, err := strconv.ParseFloat(s, 64)
+		return err == nil
+	},
+	"bool": func(s string) bool {
+		_, err := strconv.ParseBool(s)
+		return err == nil
+	},
+	"alpha": compileValidator(`[a-zA-Z]+`),
+	"alnum": compileValidator(`[a-zA-Z0-9]+`),
+	"slug":  isSlug,
+	"uuid":  isUUID,
+	"date": func(s string) bool {
+		_, err := time.Parse(dateLayout, s)
+		return err == nil
+	},
+}
+
+// dateLayout is the layout of date params.
+const dateLayout = "2006-01-02"
+
+// isSlug reports whether s is made of lowercase letters, digits and
+// single hyphens between them.
+func isSlug(s string) bool {
+	if s == "" || s[0] == '-' || s[len(s)-1] == '-' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '-' {
+			if s[i-1] == '-' {
+				return false
+			}
+		} else if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// isUUID reports whether s is a UUID in its canonical
+// 8-4-4-4-12 hex form.
+func isUUID(s string) bool {
+	_, err := parseUUID(s)
+	return err == nil
+}
+
+// Constraint registers a named param type for the routes of the routing
+// and its groups. The pattern must match the whole value of the param.
+//
+//  Example:
+//
+//  r.Constraint("year", `\d{4}`)
+//  r.Get("/archive/{y:year}", controller.Archive)
+func (r *Routing) Constraint(name string, test string) *Routing {
+	r._constraints[name] = compileValidator(test)
+	return r
+}
+
+// constraint returns the named param type as seen from the routing.
+func (r *Routing) constraint(name string) (func(string) bool, bool) {
+	for group := r; group != nil; group = group._parent {
+		if test, ok := group._constraints[name]; ok {
+			return test, true
+		}
+	}
+	test, ok := builtinConstraints[name]
+	return test, ok
+}
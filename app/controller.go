@@ -1,9 +1,6 @@
 package app
 
 import (
-	"compress/flate"
-	"compress/gzip"
-	"io"
 	"mime"
 	"net/http"
 	"path/filepath"
@@ -19,11 +16,30 @@ type Controller func(r *Request) string
 // you to automatically create and bind CRUD operations to
 // related methods in structs as well as automatically create
 // related and useful routing.
+//
+// See Routing.Resource for the routes created for a resource.
 type Resource interface {
-	Create(r *Request)
-	Update(r *Request)
-	Delete(r *Request)
-	View(r *Request)
+	Create(r *Request) string
+	Update(r *Request) string
+	Delete(r *Request) string
+	View(r *Request) string
+}
+
+// ResourceLister is implemented by resources that can list their items.
+type ResourceLister interface {
+	List(r *Request) string
+}
+
+// ResourceNewer is implemented by resources that have a page
+// with a form to create a new item.
+type ResourceNewer interface {
+	New(r *Request) string
+}
+
+// ResourceEditor is implemented by resources that have a page
+// with a form to edit an item.
+type ResourceEditor interface {
+	Edit(r *Request) string
 }
 
 type neuteredFileSystem struct {
@@ -52,17 +68,7 @@ func (nfs neuteredFileSystem) Open(path string) (http.File, error) {
 	return f, nil
 }
 
-// Gzip Compression
-type gzipResponseWriter struct {
-	io.Writer
-	http.ResponseWriter
-}
-
-func (w gzipResponseWriter) Write(b []byte) (int, error) {
-	return w.Writer.Write(b)
-}
-
-func StaticFileController(r *Request, prefix string, dir string, compress bool) string {
+func StaticFileController(r *Request, prefix string, dir string) string {
 	// if strings.HasSuffix(prefix, "/") {
 	// 	prefix = prefix[:len(prefix)-1]
 	// }
@@ -70,37 +76,45 @@ func StaticFileController(r *Request, prefix string, dir string, compress bool)
 
 	handler := http.StripPrefix(prefix, http.FileServer(neuteredFileSystem{http.Dir(dir)}))
 
-	writer := r.Writer
-
-	if compress {
-		r.Writer.Header().Add("Vary", "Accept-Encoding")
-		if strings.Contains(r.BaseRequest.Header.Get("Accept-Encoding"), "gzip") {
-			r.Writer.Header().Set("Content-Encoding", "gzip")
-			gz := gzip.NewWriter(r.Writer)
-			defer gz.Close()
-
-			writer = gzipResponseWriter{Writer: gz, ResponseWriter: r.Writer}
-		} else if strings.Contains(r.BaseRequest.Header.Get("Accept-Encoding"), "deflate") {
-			r.Writer.Header().Set("Content-Encoding", "deflate")
-			fw, _ := flate.NewWriter(r.Writer, flate.BestCompression)
-			defer fw.Close()
+	// file names can not hold a slash so encoded slashes never name a
+	// file, rather than being taken as separators.
+	if strings.Contains(strings.ToLower(r._escapedPath), "%2f") {
+		http.NotFound(r.Writer, r.BaseRequest)
+		return ""
+	}
 
-			writer = gzipResponseWriter{Writer: fw, ResponseWriter: r.Writer}
+	// fingerprinted URLs serve the file they stand for, which is cached
+	// for good as long as it still has the hash of the URL.
+	assets := r._app.assets()
+	if original, ok := assets.original(r.Path); ok {
+		file := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(original, prefix)))
+		if assets.current(r.Path, file) {
+			r.Writer.Header().Set("Cache-Control", assetCacheControl)
 		}
+		r.Path = original
 	}
 
-	if m, _ := regexp.MatchString("[.][a-zA-Z0-9]+$", r.URI); m {
-		index := strings.LastIndex(r.URI, ".")
-		ext := r.URI[index:len(r.URI)]
-		logger.Infof("Checking mime type for %s based on extension %s...", r.URI, ext)
+	if m, _ := regexp.MatchString("[.][a-zA-Z0-9]+$", r.Path); m {
+		index := strings.LastIndex(r.Path, ".")
+		ext := r.Path[index:len(r.Path)]
+		logger.Infof("Checking mime type for %s based on extension %s...", r.Path, ext)
 
 		responseType := mime.TypeByExtension(ext)
 		logger.Infof("Static file mime type = %s", responseType)
 		if responseType != "" {
-			r.Response.Header["Content-Type"] = responseType
+			r.Writer.Header().Set("Content-Type", withCharset(responseType))
 		}
 	}
 
-	handler.ServeHTTP(writer, r.BaseRequest)
+	// serve the cleaned path that matched the route.
+	base := *r.BaseRequest
+	u := *base.URL
+	u.Path, u.RawPath = r.Path, ""
+	if strings.HasSuffix(r.BaseRequest.URL.Path, "/") && r.Path != "/" {
+		u.Path += "/"
+	}
+	base.URL = &u
+
+	handler.ServeHTTP(r.Writer, &base)
 	return ""
 }
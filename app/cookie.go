@@ -0,0 +1,55 @@
+package app
+
+import (
+	"net/http"
+	"time"
+)
+
+// Cookie returns the value of the named request cookie and whether
+// it was sent.
+func (r *Request) Cookie(name string) (string, bool) {
+	cookie, err := r.BaseRequest.Cookie(name)
+	if err != nil {
+		return "", false
+	}
+	return cookie.Value, true
+}
+
+// Cookies returns every cookie sent with the request.
+func (r *Request) Cookies() []*http.Cookie {
+	return r.BaseRequest.Cookies()
+}
+
+// SetCookie adds a Set-Cookie header to the response. Several cookies
+// may be set on the same response. The path defaults to / when empty.
+//
+//  Example:
+//
+//  r.Response.SetCookie(&http.Cookie{
+//  	Name:     "theme",
+//  	Value:    "dark",
+//  	MaxAge:   86400,
+//  	Secure:   true,
+//  	HttpOnly: true,
+//  	SameSite: http.SameSiteLaxMode,
+//  })
+func (r *Response) SetCookie(cookie *http.Cookie) {
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	if v := cookie.String(); v != "" {
+		r.Header.Add("Set-Cookie", v)
+	}
+}
+
+// DeleteCookie tells the client to remove the named cookie.
+// The path and domain must match the ones the cookie was set with.
+func (r *Response) DeleteCookie(name string, path string, domain string) {
+	r.SetCookie(&http.Cookie{
+		Name:    name,
+		Path:    path,
+		Domain:  domain,
+		MaxAge:  -1,
+		Expires: time.Unix(0, 0),
+	})
+}
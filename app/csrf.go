@@ -0,0 +1,63 @@
+package app
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+)
+
+// names the CSRF token is kept and sent under.
+const (
+	csrfSessionKey = "_csrf_token"
+	csrfField      = "_csrf"
+	csrfHeader     = "X-CSRF-Token"
+)
+
+// CSRFToken returns the CSRF token of the session, creating it the
+// first time. Forms send it back in the _csrf field and scripts in the
+// X-CSRF-Token header.
+func (r *Request) CSRFToken() string {
+	session := r.Session()
+	if token, ok := session.Get(csrfSessionKey).(string); ok && token != "" {
+		return token
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	session.Set(csrfSessionKey, token)
+	return token
+}
+
+// CSRF is a middleware that turns away requests that may change state,
+// that is all but GET, HEAD, OPTIONS and TRACE requests, unless they
+// carry the CSRF token of the session. Add the token to forms with the
+// csrf_field view func.
+//
+// NOTE:
+//
+//  The token is kept in the session so sessions must not be turned off.
+func CSRF(next Controller) Controller {
+	return func(r *Request) string {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			return next(r)
+		}
+
+		sent := r.BaseRequest.Header.Get(csrfHeader)
+		if sent == "" {
+			if value, ok := r.Post(csrfField).(string); ok {
+				sent = value
+			}
+		}
+
+		token, _ := r.Session().Get(csrfSessionKey).(string)
+		if token == "" || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+			return r._app.errorResponse(r, http.StatusForbidden)
+		}
+		return next(r)
+	}
+}
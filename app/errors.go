@@ -0,0 +1,149 @@
+package app
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strconv"
+
+	"github.com/mcfriend99/gaga/logger"
+)
+
+// errorViewDir is the directory under the views directory that error
+// views are looked up in, named after their status code.
+const errorViewDir = "errors"
+
+// errorTemplate is the page errors are shown with when the app has no
+// handler or view for them.
+var errorTemplate = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{ .Code }} {{ .Status }}</title>
+<style>
+html, body { height: 100%; margin: 0; }
+body { display: flex; align-items: center; justify-content: center; background: #f6f7f9; color: #2d3142; font: 16px/1.5 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; }
+main { text-align: center; padding: 24px; }
+h1 { margin: 0; font-size: 96px; font-weight: 200; letter-spacing: 4px; color: #4f5d75; }
+p { margin: 8px 0 0; font-size: 20px; color: #8d99ae; }
+</style>
+</head>
+<body>
+<main>
+<h1>{{ .Code }}</h1>
+<p>{{ .Status }}</p>
+</main>
+</body>
+</html>
+`))
+
+// ErrorHandler registers the controller that responds with the error
+// page of the status code. The status of the response is already set to
+// the code when the handler runs.
+//
+// Without a handler, the view errors/{code} under the views directory is
+// rendered when it exists and a built-in page otherwise. Views get the
+// Code, Status and Path of the error as their Data. Clients preferring JSON to HTML
+// get a JSON body instead.
+//
+//  Example:
+//
+//  g.ErrorHandler(http.StatusNotFound, func(r *app.Request) string {
+//  	return r.View("errors/missing", r.Path)
+//  })
+func (g *Gaga) ErrorHandler(code int, handler Controller) {
+	if g._errorHandlers == nil {
+		g._errorHandlers = make(map[int]Controller)
+	}
+	g._errorHandlers[code] = handler
+}
+
+// errorResponse sets the status of the response to the error code
+// and returns the body of the error page.
+func (g *Gaga) errorResponse(r *Request, code int) string {
+	r.Response.StatusCode = code
+	r.Response.ContentType = ""
+	r.Response._kind = bodyString
+
+	// errors while responding with an error page get the built-in page.
+	if r._inErrorPage {
+		return g.builtinErrorPage(r, code)
+	}
+	r._inErrorPage = true
+	defer func() {
+		r._inErrorPage = false
+	}()
+
+	if handler, ok := g._errorHandlers[code]; ok {
+		return handler(r)
+	}
+
+	if negotiate(r.BaseRequest.Header.Get("Accept"), []string{"text/html", "application/json"}) == "application/json" {
+		return r.JSON(map[string]interface{}{
+			"status": code,
+			"error":  http.StatusText(code),
+		})
+	}
+
+	name := errorViewDir + "/" + strconv.Itoa(code)
+	if _, err := os.Stat(g.views().file(name)); err == nil {
+		html, err := g.views().render(name, r, errorPageData(r, code))
+		if err == nil {
+			r.Response.ContentType = "text/html; charset=utf-8"
+			return html
+		}
+		logger.Error("Failed to render error view:", err)
+	}
+
+	return g.builtinErrorPage(r, code)
+}
+
+// builtinErrorPage returns the built-in page of the error code.
+func (g *Gaga) builtinErrorPage(r *Request, code int) string {
+	r.Response.StatusCode = code
+	r.Response.ContentType = "text/html; charset=utf-8"
+	r.Response._kind = bodyString
+
+	var buffer bytes.Buffer
+	if err := errorTemplate.Execute(&buffer, errorPageData(r, code)); err != nil {
+		logger.Error("Failed to render error page:", err)
+		return strconv.Itoa(code) + " " + http.StatusText(code)
+	}
+	return buffer.String()
+}
+
+// errorPageData returns what error pages are rendered with.
+func errorPageData(r *Request, code int) map[string]interface{} {
+	return map[string]interface{}{
+		"Code":   code,
+		"Status": http.StatusText(code),
+		"Path":   r.Path,
+	}
+}
+
+// recoverPanic responds with a 500 error page when the controller
+// handling the request panics.
+func (g *Gaga) recoverPanic(r *Request, result *string) {
+	err := recover()
+	if err == nil {
+		return
+	}
+	if err == http.ErrAbortHandler {
+		panic(err)
+	}
+
+	logger.Errorf("Recovered from panic serving %s %s: %v\n%s", r.Method, r.URI, err, debug.Stack())
+
+	// an error handler that panics as well gets the built-in page.
+	defer func() {
+		if recover() != nil {
+			*result = g.builtinErrorPage(r, http.StatusInternalServerError)
+		}
+	}()
+
+	r.Response.Header = make(http.Header)
+	*result = g.errorResponse(r, http.StatusInternalServerError)
+}
@@ -0,0 +1,177 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mcfriend99/gaga/logger"
+)
+
+// flashCookie is the name of the cookie holding flashed values when
+// sessions are turned off. Its values are only trusted for a few minutes
+// in case the client fails to remove it.
+const (
+	flashCookie       = "gaga_flash"
+	flashCookieMaxAge = 5 * 60
+)
+
+// keys of the flashed old input and validation errors.
+const (
+	flashOldInput = "_old_input"
+	flashErrors   = "_errors"
+)
+
+// Flash sets a value that is only available to the next request through
+// GetFlash, such as a message to show after a redirect.
+// Flashed values are kept in the session or, when sessions are turned
+// off, in a signed cookie.
+//
+//  Example:
+//
+//  func SaveProfile(r *app.Request) string {
+//  	// ...
+//  	r.Flash("success", "Saved!")
+//  	return r.Redirect("/profile", http.StatusSeeOther)
+//  }
+func (r *Request) Flash(key string, value interface{}) {
+	if !r.flashInCookie() {
+		r.Session().Flash(key, value)
+		return
+	}
+
+	if r._flashOut == nil {
+		r._flashOut = make(map[string]interface{})
+	}
+	r._flashOut[key] = value
+}
+
+// GetFlash returns the value flashed by the previous request
+// or nil if there is none.
+func (r *Request) GetFlash(key string) interface{} {
+	if !r.flashInCookie() {
+		return r.Session().GetFlash(key)
+	}
+
+	if r._flashIn == nil {
+		r._flashIn = make(map[string]interface{})
+		if value, ok := r.SignedCookie(flashCookie); ok {
+			if err := json.Unmarshal([]byte(value), &r._flashIn); err != nil {
+				logger.Warn("Failed to read flashed values:", err)
+			}
+		}
+	}
+	return r._flashIn[key]
+}
+
+// WithInput flashes the form fields and query string parameters of the
+// request so a form can be filled again with Old after a redirect.
+// Fields with "password" in their name are left out.
+//
+//  Example:
+//
+//  if err := r.Bind(&form); err != nil {
+//  	r.WithInput()
+//  	r.WithErrors(err)
+//  	return r.Redirect("/register", http.StatusSeeOther)
+//  }
+func (r *Request) WithInput() {
+	r.parseBody()
+
+	input := make(map[string][]string)
+	add := func(key string, values []string) {
+		if !strings.Contains(strings.ToLower(key), "password") {
+			input[key] = append(input[key], values...)
+		}
+	}
+	for key, values := range r._getsData {
+		add(key, values)
+	}
+	for key, values := range r._postsData {
+		add(key, values)
+	}
+	for key := range r._jsonData {
+		add(key, r.PostAll(key))
+	}
+
+	r.Flash(flashOldInput, input)
+}
+
+// Old returns the first value of the named field flashed with WithInput
+// by the previous request or an empty string if there is none.
+func (r *Request) Old(name string) string {
+	if values := r.OldAll(name); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// OldAll returns every value of the named field flashed with WithInput
+// by the previous request.
+func (r *Request) OldAll(name string) []string {
+	input := flashedStrings(r.GetFlash(flashOldInput))
+	return append(input[name], input[name+"[]"]...)
+}
+
+// WithErrors flashes the validation errors, or the message of any other
+// error, so the next request can show them with Errors.
+func (r *Request) WithErrors(err error) {
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		errs = ValidationErrors{"": {err.Error()}}
+	}
+	r.Flash(flashErrors, map[string][]string(errs))
+}
+
+// Errors returns the validation errors flashed with WithErrors by the
+// previous request. It is empty when there were none.
+func (r *Request) Errors() ValidationErrors {
+	return ValidationErrors(flashedStrings(r.GetFlash(flashErrors)))
+}
+
+// flashedStrings returns a flashed map of string slices. Values kept in
+// files or cookies come back from JSON as generic maps and slices.
+func flashedStrings(value interface{}) map[string][]string {
+	switch value := value.(type) {
+	case map[string][]string:
+		return value
+	case map[string]interface{}:
+		result := make(map[string][]string, len(value))
+		for key, items := range value {
+			list, _ := items.([]interface{})
+			for _, item := range list {
+				result[key] = append(result[key], fmt.Sprint(item))
+			}
+		}
+		return result
+	}
+	return map[string][]string{}
+}
+
+// flashInCookie reports whether flashed values are kept in a cookie
+// because sessions are turned off.
+func (r *Request) flashInCookie() bool {
+	_, ok := r._app.sessions()._store.(nullStore)
+	return ok
+}
+
+// saveFlash sets the flash cookie to the values flashed by the request
+// or removes it once its values have been read.
+func (r *Request) saveFlash() {
+	if len(r._flashOut) > 0 {
+		b, err := json.Marshal(r._flashOut)
+		if err != nil {
+			logger.Error("Failed to flash values:", err)
+			return
+		}
+		r.SetSignedCookie(&http.Cookie{Name: flashCookie, Value: string(b), MaxAge: flashCookieMaxAge, HttpOnly: true})
+		return
+	}
+
+	// requests that never read the flashed values, such as one for an
+	// asset, leave them for the next request.
+	if _, ok := r.Cookie(flashCookie); ok && r._flashIn != nil {
+		r.Response.DeleteCookie(flashCookie, "/", "")
+	}
+}
@@ -0,0 +1,219 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// default layout of the date view func.
+const defaultDateLayout = "Jan 2, 2006"
+
+// errNoRequest is returned by view funcs taking the request when
+// a view is executed without one.
+var errNoRequest = errors.New("only available to views rendered for a request")
+
+// Funcs registers funcs that every view can use along with the standard
+// ones. Funcs with the name of a standard func replace it.
+// It must be called before the app starts serving, views are parsed
+// with the funcs registered by then.
+//
+//  Example:
+//
+//  g.Funcs(template.FuncMap{
+//  	"upper": strings.ToUpper,
+//  })
+func (g *Gaga) Funcs(funcs template.FuncMap) {
+	if g._views != nil {
+		panic("view funcs must be registered before views are parsed")
+	}
+	if g._funcs == nil {
+		g._funcs = make(template.FuncMap)
+	}
+	for name, fn := range funcs {
+		g._funcs[name] = fn
+	}
+}
+
+// viewFuncs returns the funcs every view can use.
+//
+//  url "user.edit" "id" 42    path of a named route, params in pairs
+//  asset "app.css"            URL of a file served by a Static route, fingerprinted
+//  csrf_field .Request        hidden input with the CSRF token
+//  csrf_token .Request        the CSRF token
+//  old .Request "email"       field value flashed with Request.WithInput
+//  config "site.name"         value of the custom config at a dotted path
+//  date .Time "2006-01-02"    formatted time, the layout is optional
+//  number .Price 2            number with thousands separators
+//  markdown .Body             markdown rendered to HTML
+//
+// Funcs taking the request only work in views rendered for one, which
+// get it as ViewData.Request.
+func (g *Gaga) viewFuncs() template.FuncMap {
+	funcs := template.FuncMap{
+		"url":      g.urlFunc,
+		"asset":    g.Asset,
+		"config":   g.configValue,
+		"date":     formatDate,
+		"number":   formatNumber,
+		"markdown": func(text string) template.HTML { return template.HTML(Markdown(text)) },
+
+		"csrf_field": csrfInput,
+		"csrf_token": func(r *Request) (string, error) {
+			if r == nil {
+				return "", errNoRequest
+			}
+			return r.CSRFToken(), nil
+		},
+		"old": func(r *Request, name string) (string, error) {
+			if r == nil {
+				return "", errNoRequest
+			}
+			return r.Old(name), nil
+		},
+	}
+	for name, fn := range g._funcs {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// csrfInput returns a hidden input with the CSRF token of the request.
+func csrfInput(r *Request) (template.HTML, error) {
+	if r == nil {
+		return "", errNoRequest
+	}
+	return template.HTML(`<input type="hidden" name="` + csrfField + `" value="` +
+		template.HTMLEscapeString(r.CSRFToken()) + `">`), nil
+}
+
+// urlFunc generates the path to the named route with params given as
+// name and value pairs.
+func (g *Gaga) urlFunc(name string, pairs ...interface{}) (string, error) {
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("url %q needs params in name and value pairs", name)
+	}
+
+	params := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		params[fmt.Sprint(pairs[i])] = pairs[i+1]
+	}
+	return g.URL(name, params)
+}
+
+// configValue returns the value of the custom config at the dotted path
+// or nil if there is none. Numbers index arrays.
+//
+//  Example:
+//
+//  // "custom": {"site": {"name": "Gaga", "links": ["/a", "/b"]}}
+//  g.configValue("site.name")    // => Gaga
+//  g.configValue("site.links.1") // => /b
+func (g *Gaga) configValue(path string) interface{} {
+	value := g.Config.Custom
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			value = v[i]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// formatDate formats a time.Time or *time.Time with the layout,
+// Jan 2, 2006 by default. A nil or zero time formats as an empty string.
+func formatDate(value interface{}, layout ...string) (string, error) {
+	var t time.Time
+	switch v := value.(type) {
+	case time.Time:
+		t = v
+	case *time.Time:
+		if v == nil {
+			return "", nil
+		}
+		t = *v
+	default:
+		return "", fmt.Errorf("date needs a time, not %T", value)
+	}
+
+	if t.IsZero() {
+		return "", nil
+	}
+	if len(layout) > 0 {
+		return t.Format(layout[0]), nil
+	}
+	return t.Format(defaultDateLayout), nil
+}
+
+// formatNumber formats a number with commas between thousands and the
+// given number of decimals, 0 for integers and 2 for floats by default.
+//
+//  Example:
+//
+//  formatNumber(1234567.891)    // => 1,234,567.89
+//  formatNumber(1234567.891, 1) // => 1,234,567.9
+func formatNumber(value interface{}, decimals ...int) (string, error) {
+	places := -1
+	if len(decimals) > 0 && decimals[0] >= 0 {
+		places = decimals[0]
+	}
+
+	// integers are formatted as they are so large ones keep every digit.
+	var s string
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s = strconv.FormatInt(v.Int(), 10)
+		if places > 0 {
+			s += "." + strings.Repeat("0", places)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s = strconv.FormatUint(v.Uint(), 10)
+		if places > 0 {
+			s += "." + strings.Repeat("0", places)
+		}
+	case reflect.Float32, reflect.Float64:
+		n := v.Float()
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return strconv.FormatFloat(n, 'f', -1, 64), nil
+		}
+		if places < 0 {
+			places = 2
+		}
+		s = strconv.FormatFloat(n, 'f', places, 64)
+	default:
+		return "", fmt.Errorf("number needs a number, not %T", value)
+	}
+
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, fraction := s, ""
+	if i := strings.Index(s, "."); i >= 0 {
+		whole, fraction = s[:i], s[i:]
+	}
+
+	var grouped strings.Builder
+	grouped.WriteString(sign)
+	for i, c := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(c)
+	}
+	return grouped.String() + fraction, nil
+}
@@ -1,208 +1,308 @@
 package app
 
 import (
-	"compress/flate"
-	"compress/gzip"
 	"fmt"
+	"html/template"
+	"mime"
 	"net/http"
-	"regexp"
+	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/mcfriend99/gaga/logger"
 )
 
 // Gaga main struct
 type Gaga struct {
-	Config          *Config
-	RouteGenerator  func(*Routing)
+	Config         *Config
+	RouteGenerator func(*Routing)
+
+	// NotFoundHandler responds to requests that match no route.
+	// It is kept for compatibility, see ErrorHandler.
 	NotFoundHandler func(*Request) string
-}
 
-func (g Gaga) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// initialize routing
-	routing := Routing{
-		Routes:          make(map[string][]Route),
-		_shouldCompress: g.Config.SEO.Compress,
-	}
+	// SessionStore is where sessions are kept. When nil, the store
+	// is picked from the session configuration.
+	SessionStore SessionStore
+
+	_router       *router
+	_routesOnce   sync.Once
+	_middlewares  []Middleware
+	_sessions     *sessionManager
+	_sessionsOnce sync.Once
+	_cookies      *CookieCodec
+	_cookiesOnce  sync.Once
+	_views        *viewEngine
+	_viewsOnce    sync.Once
+	_funcs        template.FuncMap
+	_assets       *assetManifest
+	_assetsOnce   sync.Once
+
+	_errorHandlers map[int]Controller
+}
 
-	// get user routes...
-	g.RouteGenerator(&routing)
+func (g *Gaga) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g._routesOnce.Do(g.buildRoutes)
 
 	request := Request{
 		URI:    r.RequestURI,
+		Path:   r.URL.Path,
 		Method: r.Method,
 		Header: make(map[string]string),
 		Params: make(map[string]string),
 		Response: Response{
 			StatusCode:  http.StatusNotFound,
 			ContentType: "",
-			Header:      make(map[string]string),
+			Header:      make(http.Header),
 		},
 		Writer:      w,
 		BaseRequest: r,
+		_app:        g,
 		_filesData:  make(map[string]interface{}),
-		_postsData:  make(map[string]interface{}),
-		_getsData:   make(map[string]interface{}),
+		_postsData:  make(url.Values),
+		_jsonData:   make(map[string]interface{}),
+		_getsData:   r.URL.Query(),
 	}
 
 	// populate request bodies...
 	for s := range r.Header {
 		request.Header[s] = r.Header.Get(s)
 	}
-	for s := range r.Form {
-		request._getsData[s] = r.Form.Get(s)
-	}
-	for s := range r.PostForm {
-		request._postsData[s] = r.PostForm.Get(s)
+
+	handler := g.dispatch(&request)
+	result := g.handle(&request, chain(handler, g._middlewares))
+	g.writeResponse(&request, result)
+
+	for i := len(request._afterResponse) - 1; i >= 0; i-- {
+		request._afterResponse[i]()
 	}
+}
 
-	if r.MultipartForm != nil && r.MultipartForm.File != nil {
-		for s := range r.MultipartForm.File {
-			request._filesData[s] = r.MultipartForm.File[s]
+// dispatch matches the request to a route and returns the controller
+// that should handle it. Routes are matched on the cleaned and decoded
+// path of the request, the query string has no part in it.
+func (g *Gaga) dispatch(request *Request) Controller {
+	r := request.BaseRequest
+
+	escaped := r.URL.EscapedPath()
+	parts, canonical, err := cleanPath(escaped)
+	if err != nil {
+		return func(r *Request) string {
+			return g.errorResponse(r, http.StatusBadRequest)
 		}
 	}
+	request.Path = "/" + strings.Join(parts, "/")
+	request._escapedPath = canonical
 
-	// match route...
-	result := ""
-	routeFound := false
-	var _route *Route = nil
-
-	var routesToSearch []Route
-
-	if routes, err := routing.Routes[r.Method]; err {
-		routesToSearch = routes
+	if g.Config.Server.CleanPath == "redirect" && canonical != escaped {
+		return redirectPath(canonical)
 	}
 
-	if routes, err := routing.Routes[""]; err {
-		routesToSearch = append(routesToSearch, routes...)
-	}
+	route, params := g._router.find(r.Method, r.Host, parts)
 
-	if len(routesToSearch) > 0 {
-		for _, route := range routesToSearch {
-			routeFound = route.Path == r.RequestURI
+	// GET routes answer HEAD requests without a body.
+	if route == nil && r.Method == http.MethodHead {
+		if route, params = g._router.find(http.MethodGet, r.Host, parts); route != nil {
+			request.Writer = headResponseWriter{request.Writer}
+		}
+	}
 
-			// static routes should use a prefix with check.
-			if route._isStatic {
-				routeFound = strings.HasPrefix(r.RequestURI, route.Path)
-			}
+	if route != nil {
+		if g.Config.Server.TrailingSlash == "redirect" && route._dir == "" &&
+			len(parts) > 0 && strings.HasSuffix(escaped, "/") {
+			return redirectPath(strings.TrimSuffix(canonical, "/"))
+		}
 
-			if !routeFound {
-				// do a regex check.
-				// also, this is the only place where we can have route parameters.
-				m := regexp.MustCompile(`/{([^}?]+)([?])?}/?`)
-				path := m.ReplaceAllString(route.Path, "/?(?P<$1>[^/?#]+)$2/?")
-
-				exp := regexp.MustCompile(fmt.Sprintf("^%s$", path))
-				routeFound = exp.MatchString(r.RequestURI)
-
-				if routeFound {
-					match := exp.FindStringSubmatch(r.RequestURI)
-					for i, name := range exp.SubexpNames() {
-						if i != 0 && name != "" {
-							// check param validator
-							if v, e := route._paramValidators[name]; e && match[i] != "" {
-								if k, e := regexp.MatchString(v, match[i]); e != nil || !k {
-									routeFound = false
-									break
-								}
-							}
-
-							// use default param if the param is empty
-							if v, e := route._paramDefaults[name]; e && match[i] == "" {
-								request.Params[name] = v
-							} else {
-								request.Params[name] = match[i]
-							}
-						}
-					}
-				}
-			}
+		request.Params = params
+		request.Response.StatusCode = http.StatusOK
+		request._route = route
+		return route._handler
+	}
 
-			if routeFound {
-				_route = &route
-				request.Response.StatusCode = http.StatusOK
-				if route.Controller != nil {
-					result = route.Controller(&request)
-				}
-				break
+	if allowed := g._router.allowed(r.Host, parts); len(allowed) > 0 {
+		request.Response.Header.Set("Allow", strings.Join(allowed, ", "))
+		if r.Method == http.MethodOptions {
+			return func(r *Request) string {
+				r.Response.StatusCode = http.StatusNoContent
+				return ""
 			}
 		}
+		return func(r *Request) string {
+			return g.errorResponse(r, http.StatusMethodNotAllowed)
+		}
 	}
 
-	// handle not found...
-	if !routeFound {
-		// handle with 404 handler here...
-		// if non is given, do the default below.
-		if g.NotFoundHandler != nil {
-			request.Response.StatusCode = http.StatusOK
-			result = g.NotFoundHandler(&request)
+	return g.notFound
+}
+
+// redirectPath returns a controller that permanently redirects to the
+// escaped path, keeping the query string.
+func redirectPath(location string) Controller {
+	return func(r *Request) string {
+		if r.BaseRequest.URL.RawQuery != "" {
+			r.Response.Header.Set("Location", location+"?"+r.BaseRequest.URL.RawQuery)
 		} else {
-			// @TODO: use beautiful template based 404 page.
-			result = "404 page not found"
+			r.Response.Header.Set("Location", location)
+		}
+		r.Response.StatusCode = http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			r.Response.StatusCode = http.StatusMovedPermanently
 		}
+		return ""
 	}
+}
 
-	// write response data...
-	responseType := "text/html; charset=utf-8"
-	w.Header().Set("Content-Type", responseType)
+// handle runs the controller, turning panics into 500 errors.
+func (g *Gaga) handle(r *Request, controller Controller) (result string) {
+	defer g.recoverPanic(r, &result)
+	return controller(r)
+}
 
-	for key, value := range request.Response.Header {
-		w.Header().Set(key, value)
+// notFound responds to requests that match no route.
+func (g *Gaga) notFound(r *Request) string {
+	if g.NotFoundHandler != nil {
+		r.Response.StatusCode = http.StatusNotFound
+		return g.NotFoundHandler(r)
 	}
+	return g.errorResponse(r, http.StatusNotFound)
+}
+
+// headResponseWriter drops the body of responses to HEAD requests.
+type headResponseWriter struct {
+	http.ResponseWriter
+}
 
-	contentLength := len(result)
+func (w headResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
 
-	if _route != nil && _route._isStatic {
-		contentLength, _ = strconv.Atoi(w.Header().Get("Content-Length"))
-	} else {
-		w.WriteHeader(request.Response.StatusCode)
+// writeResponse writes the response headers and body to the client.
+// The body is the result of the controller unless a body helper such
+// as Request.JSON replaced it.
+func (g *Gaga) writeResponse(r *Request, result string) {
+	w := r.Writer
+	response := &r.Response
+
+	// the session and flashed values may set cookies so they are saved
+	// before any header is sent.
+	if r._session != nil {
+		g.sessions().save(r)
+	}
+	r.saveFlash()
+
+	if response.ContentType != "" {
+		w.Header().Set("Content-Type", withCharset(response.ContentType))
+	}
+
+	for key, values := range response.Header {
+		// Vary lists everything the response depends on, including what
+		// middlewares such as Compress added to the writer.
+		if key == "Vary" {
+			for _, value := range values {
+				addVary(w.Header(), value)
+			}
+			continue
+		}
+
+		w.Header().Del(key)
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
 	}
 
-	if result != "" {
-		writer := w.Write
+	// static files are written out by the file server.
+	if r._route != nil && r._route._isStatic {
+		return
+	}
 
-		// only compress objects exceeding 128 byte
-		if g.Config.SEO.Compress && contentLength > g.Config.SEO.CompressionThreshold {
-			w.Header().Add("Vary", "Accept-Encoding")
+	var err error
+	switch response._kind {
+	case bodyFile:
+		http.ServeContent(w, r.BaseRequest, response.fileName(), response._modTime, response._file)
+	case bodyStream:
+		w.WriteHeader(response.StatusCode)
+		if bodyAllowed(response.StatusCode) {
+			err = stream(w, response._reader)
+		}
+	default:
+		body := []byte(result)
+		if response._kind == bodyBytes {
+			body = response._bytes
+		}
 
-			// prioritize gzip over deflate
-			if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
-				w.Header().Set("Content-Encoding", "gzip")
-				gz := gzip.NewWriter(w)
-				defer gz.Close()
+		// without a content type from the controller, it is sniffed from
+		// the body the way http.ResponseWriter would.
+		if w.Header().Get("Content-Type") == "" && len(body) > 0 {
+			w.Header().Set("Content-Type", http.DetectContentType(body))
+		}
 
-				writer = gz.Write
-			} else if strings.Contains(r.Header.Get("Accept-Encoding"), "deflate") {
-				w.Header().Set("Content-Encoding", "deflate")
+		if bodyAllowed(response.StatusCode) {
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		}
+		w.WriteHeader(response.StatusCode)
 
-				fw, _ := flate.NewWriter(w, flate.BestCompression)
-				defer fw.Close()
+		if len(body) > 0 && bodyAllowed(response.StatusCode) {
+			_, err = w.Write(body)
+		}
+	}
 
-				writer = fw.Write
-			}
+	if err != nil {
+		logger.Error("Failed to write response:", err)
+	}
+}
+
+// addVary adds the header names in value to the Vary header unless
+// it already lists them.
+func addVary(header http.Header, value string) {
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
 		}
 
-		if _, err := writer([]byte(result)); err != nil {
-			logger.Error("Failed to write response:", err)
+		listed := false
+		for _, vary := range header["Vary"] {
+			for _, v := range strings.Split(vary, ",") {
+				if strings.EqualFold(strings.TrimSpace(v), name) {
+					listed = true
+				}
+			}
 		}
+		if !listed {
+			header.Add("Vary", name)
+		}
+	}
+}
+
+// withCharset adds the utf-8 charset to textual content types
+// that do not name their charset.
+func withCharset(contentType string) string {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || params["charset"] != "" {
+		return contentType
 	}
 
-	// logs request
-	logMethod := logger.Infof
-	if request.Response.StatusCode < 200 || request.Response.StatusCode >= 399 {
-		logMethod = logger.Warnf
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "/json"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "/xml"),
+		strings.HasSuffix(mediaType, "+xml"),
+		mediaType == "application/javascript":
+		return contentType + "; charset=utf-8"
 	}
-	logMethod(`%s "%s %s %s" %d %d %s "%s"`,
-		r.RemoteAddr,
-		r.Method,
-		r.RequestURI,
-		r.Proto,
-		request.Response.StatusCode,
-		contentLength,
-		responseType,
-		r.UserAgent(),
-	)
+	return contentType
+}
+
+// Use attaches middlewares that run for every request, including
+// requests that match no route.
+//
+//  Example:
+//
+//  g.Use(app.AccessLog, app.Compress(config.SEO))
+func (g *Gaga) Use(middlewares ...Middleware) {
+	g._middlewares = append(g._middlewares, middlewares...)
 }
 
 func (g *Gaga) setupLogging() {
@@ -253,9 +353,27 @@ func (g *Gaga) Init() {
 	InitGagaMimes()
 }
 
+// buildRoutes runs the RouteGenerator once and compiles the
+// generated routes for matching.
+func (g *Gaga) buildRoutes() {
+	routing := _newRouting(make(map[string][]*Route))
+
+	// get user routes...
+	if g.RouteGenerator != nil {
+		g.RouteGenerator(routing)
+	}
+
+	g._router = _newRouter(routing)
+	g._router.useForURL()
+}
+
 func (g *Gaga) Serve() {
 	g.Init()
 	g.setupLogging()
+	g._routesOnce.Do(g.buildRoutes)
+	g.sessions()
+	g.views().precompile()
+	g.assets()
 
 	listen := fmt.Sprintf("%s:%d", g.Config.Server.ListenOn, g.Config.Server.Port)
 
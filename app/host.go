@@ -0,0 +1,131 @@
+package app
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// hostPattern is a compiled domain pattern such as {tenant}.example.com.
+type hostPattern struct {
+	_labels     []segment
+	_validators map[string]func(string) bool
+}
+
+// _newHostPattern compiles the domain of a group. Placeholders in the
+// domain are checked against the validators and param types of the group.
+func _newHostPattern(group *Routing) *hostPattern {
+	h := &hostPattern{
+		_labels:     parseHost(group._domain),
+		_validators: make(map[string]func(string) bool),
+	}
+
+	for g := group; g != nil; g = g._parent {
+		for name, test := range g._paramValidators {
+			if _, ok := h._validators[name]; !ok {
+				h._validators[name] = compileValidator(test)
+			}
+		}
+	}
+
+	for _, s := range h._labels {
+		if s.kind != segmentParam || s.constraint == "" {
+			continue
+		}
+
+		constraint, ok := group.constraint(s.constraint)
+		if !ok {
+			panic(fmt.Sprintf("unknown param type %q in domain %s", s.constraint, group._domain))
+		}
+		if test, ok := h._validators[s.value]; ok {
+			h._validators[s.value] = func(value string) bool {
+				return constraint(value) && test(value)
+			}
+		} else {
+			h._validators[s.value] = constraint
+		}
+	}
+
+	return h
+}
+
+// match reports whether the host labels match the pattern and
+// returns the params captured from them.
+func (h *hostPattern) match(labels []string) (map[string]string, bool) {
+	if len(labels) != len(h._labels) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, s := range h._labels {
+		if s.kind == segmentStatic {
+			if labels[i] != s.value {
+				return nil, false
+			}
+			continue
+		}
+
+		if test, ok := h._validators[s.value]; ok && !test(labels[i]) {
+			return nil, false
+		}
+		params[s.value] = labels[i]
+	}
+	return params, true
+}
+
+// splitHost breaks a request host into its labels, leaving out the port.
+func splitHost(host string) []string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" {
+		return nil
+	}
+	return strings.Split(host, ".")
+}
+
+// parseHost compiles a domain pattern into its labels.
+func parseHost(pattern string) []segment {
+	var labels []segment
+	for _, label := range splitHost(pattern) {
+		if strings.HasPrefix(label, "{") && strings.HasSuffix(label, "}") {
+			name := label[1 : len(label)-1]
+			constraint := ""
+			if i := strings.Index(name, ":"); i >= 0 {
+				name, constraint = name[:i], name[i+1:]
+			}
+			labels = append(labels, segment{kind: segmentParam, value: name, constraint: constraint})
+		} else {
+			labels = append(labels, segment{kind: segmentStatic, value: label})
+		}
+	}
+	return labels
+}
+
+// Domain creates a group of routes that only match requests for hosts
+// matching the pattern. Placeholders in the pattern capture a whole
+// label of the host into the params of the request.
+// Routes outside of any domain match requests for every host and serve
+// as the fallback when no route of a matching domain does.
+//
+//  Example:
+//
+//  r.Domain("{tenant}.example.com", func(g *app.Routing) {
+//  	g.Get("/", controller.TenantHome)
+//  })
+func (r *Routing) Domain(pattern string, routes func(*Routing)) *Routing {
+	group := _newRouting(r.Routes)
+	group._prefix = r._prefix
+	group._parent = r
+	group._domain = pattern
+
+	root := r
+	for root._parent != nil {
+		root = root._parent
+	}
+	root._domains = append(root._domains, group)
+
+	routes(group)
+	return group
+}
@@ -0,0 +1,186 @@
+package app
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// allValues returns every value of the named key, including the values
+// sent with the array form of the key, e.g. tags[]=a&tags[]=b.
+func allValues(values url.Values, name string) []string {
+	all := append([]string{}, values[name]...)
+	return append(all, values[name+"[]"]...)
+}
+
+// GetAll returns every value of the named query string parameter.
+//
+//  Example:
+//
+//  // ?tag=a&tag=b or ?tag[]=a&tag[]=b
+//  r.GetAll("tag") // => [a b]
+func (r *Request) GetAll(name string) []string {
+	return allValues(r._getsData, name)
+}
+
+// PostAll returns every value of the named form field. Values of the
+// top level keys of JSON bodies are formatted as strings, with each item
+// of an array being a value.
+func (r *Request) PostAll(name string) []string {
+	r.parseBody()
+	if values := allValues(r._postsData, name); len(values) > 0 {
+		return values
+	}
+
+	switch value := r._jsonData[name].(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	default:
+		return []string{fmt.Sprint(value)}
+	}
+}
+
+// HeaderValues returns every value of the named request header.
+func (r *Request) HeaderValues(name string) []string {
+	return r.BaseRequest.Header.Values(name)
+}
+
+// GetMap returns the query string parameters named in the bracket form
+// of name as a nested map. Keys ending with [] hold a slice of strings,
+// all others hold a string.
+//
+//  Example:
+//
+//  // ?user[name]=gaga&user[address][city]=lagos&user[tags][]=a
+//  r.GetMap("user")
+//  // => map[address:map[city:lagos] name:gaga tags:[a]]
+func (r *Request) GetMap(name string) map[string]interface{} {
+	return nestedValues(r._getsData, name)
+}
+
+// PostMap returns the form fields named in the bracket form of name as
+// a nested map. See GetMap.
+func (r *Request) PostMap(name string) map[string]interface{} {
+	r.parseBody()
+	return nestedValues(r._postsData, name)
+}
+
+// nestedValues collects the values of keys such as name[a][b] into
+// nested maps.
+func nestedValues(values url.Values, name string) map[string]interface{} {
+	result := make(map[string]interface{})
+	for key, vals := range values {
+		if !strings.HasPrefix(key, name+"[") || !strings.HasSuffix(key, "]") {
+			continue
+		}
+
+		path := strings.Split(key[len(name)+1:len(key)-1], "][")
+		isList := path[len(path)-1] == ""
+		if isList {
+			path = path[:len(path)-1]
+		}
+		if len(path) == 0 {
+			continue
+		}
+
+		current := result
+		for _, part := range path[:len(path)-1] {
+			next, ok := current[part].(map[string]interface{})
+			if !ok {
+				next = make(map[string]interface{})
+				current[part] = next
+			}
+			current = next
+		}
+
+		last := path[len(path)-1]
+		if isList {
+			list, _ := current[last].([]string)
+			current[last] = append(list, vals...)
+		} else if len(vals) > 0 {
+			current[last] = vals[0]
+		}
+	}
+	return result
+}
+
+// getString returns the first value of the named query string
+// parameter and whether it was given.
+func (r *Request) getString(name string) (string, bool) {
+	values := r.GetAll(name)
+	if len(values) == 0 || values[0] == "" {
+		return "", false
+	}
+	return values[0], true
+}
+
+// GetInt returns the named query string parameter as an int.
+// The default is returned when the parameter is missing and, along
+// with the error, when it is not an int.
+func (r *Request) GetInt(name string, def int) (int, error) {
+	value, ok := r.getString(name)
+	if !ok {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return def, fmt.Errorf("query param %q is not an integer", name)
+	}
+	return n, nil
+}
+
+// GetFloat returns the named query string parameter as a float64.
+// See GetInt for how defaults are used.
+func (r *Request) GetFloat(name string, def float64) (float64, error) {
+	value, ok := r.getString(name)
+	if !ok {
+		return def, nil
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return def, fmt.Errorf("query param %q is not a number", name)
+	}
+	return n, nil
+}
+
+// GetBool returns the named query string parameter as a bool.
+// "on" counts as true the way checkboxes send it.
+// See GetInt for how defaults are used.
+func (r *Request) GetBool(name string, def bool) (bool, error) {
+	value, ok := r.getString(name)
+	if !ok {
+		return def, nil
+	}
+	if value == "on" {
+		return true, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return def, fmt.Errorf("query param %q is not a boolean", name)
+	}
+	return b, nil
+}
+
+// GetTime returns the named query string parameter as a time in the
+// RFC 3339, YYYY-MM-DDTHH:MM or YYYY-MM-DD form.
+// See GetInt for how defaults are used.
+func (r *Request) GetTime(name string, def time.Time) (time.Time, error) {
+	value, ok := r.getString(name)
+	if !ok {
+		return def, nil
+	}
+	for _, layout := range bindTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return def, fmt.Errorf("query param %q is not a time", name)
+}
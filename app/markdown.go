@@ -0,0 +1,148 @@
+package app
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// patterns of the inline markdown elements, applied to escaped text.
+var (
+	markdownCode     = regexp.MustCompile("`([^`]+)`")
+	markdownImage    = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)\)`)
+	markdownLink     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	markdownStrong   = regexp.MustCompile(`(\*\*|__)(\S(?:.*?\S)?)(\*\*|__)`)
+	markdownEm       = regexp.MustCompile(`(^|[^\w*])[*_](\S(?:.*?\S)?)[*_]`)
+	markdownHeading  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+	markdownOrdered  = regexp.MustCompile(`^\d+[.)]\s+(.*)$`)
+	markdownBullet   = regexp.MustCompile(`^[-*+]\s+(.*)$`)
+	markdownRule     = regexp.MustCompile(`^(-{3,}|\*{3,}|_{3,})$`)
+	markdownSafeLink = regexp.MustCompile(`^(https?://|mailto:|/|#|\.)`)
+)
+
+// Markdown renders a small, safe subset of markdown to HTML: headings,
+// paragraphs, block quotes, lists, fenced code blocks, horizontal rules,
+// emphasis, inline code, links and images. Any HTML in the text is
+// escaped and links are limited to http, https, mailto and relative URLs.
+func Markdown(text string) string {
+	var out strings.Builder
+	var paragraph []string
+	var list string
+
+	closeParagraph := func() {
+		if len(paragraph) > 0 {
+			out.WriteString("<p>" + markdownInline(strings.Join(paragraph, " ")) + "</p>\n")
+			paragraph = nil
+		}
+	}
+	closeList := func() {
+		if list != "" {
+			out.WriteString("</" + list + ">\n")
+			list = ""
+		}
+	}
+	openList := func(tag string) {
+		if list != tag {
+			closeList()
+			out.WriteString("<" + tag + ">\n")
+			list = tag
+		}
+	}
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			closeParagraph()
+			closeList()
+
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, html.EscapeString(lines[i]))
+			}
+			out.WriteString("<pre><code>" + strings.Join(code, "\n") + "</code></pre>\n")
+		case trimmed == "":
+			closeParagraph()
+			closeList()
+		case markdownHeading.MatchString(trimmed):
+			closeParagraph()
+			closeList()
+
+			m := markdownHeading.FindStringSubmatch(trimmed)
+			level := strconv.Itoa(len(m[1]))
+			out.WriteString("<h" + level + ">" + markdownInline(m[2]) + "</h" + level + ">\n")
+		case markdownRule.MatchString(trimmed):
+			closeParagraph()
+			closeList()
+			out.WriteString("<hr>\n")
+		case strings.HasPrefix(trimmed, ">"):
+			closeParagraph()
+			closeList()
+
+			var quote []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				quote = append(quote, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")))
+			}
+			i--
+			out.WriteString("<blockquote>\n" + Markdown(strings.Join(quote, "\n")) + "</blockquote>\n")
+		case markdownBullet.MatchString(trimmed):
+			closeParagraph()
+			openList("ul")
+			out.WriteString("<li>" + markdownInline(markdownBullet.FindStringSubmatch(trimmed)[1]) + "</li>\n")
+		case markdownOrdered.MatchString(trimmed):
+			closeParagraph()
+			openList("ol")
+			out.WriteString("<li>" + markdownInline(markdownOrdered.FindStringSubmatch(trimmed)[1]) + "</li>\n")
+		default:
+			closeList()
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	closeParagraph()
+	closeList()
+
+	return out.String()
+}
+
+// markdownInline renders the inline elements of a block of text.
+func markdownInline(text string) string {
+	text = html.EscapeString(text)
+
+	// code spans, images and links are set aside so emphasis is never
+	// rendered inside them.
+	var spans []string
+	aside := func(span string) string {
+		spans = append(spans, span)
+		return "\x00" + strconv.Itoa(len(spans)-1) + "\x00"
+	}
+
+	text = markdownCode.ReplaceAllStringFunc(text, func(s string) string {
+		return aside("<code>" + markdownCode.FindStringSubmatch(s)[1] + "</code>")
+	})
+	text = markdownImage.ReplaceAllStringFunc(text, func(s string) string {
+		m := markdownImage.FindStringSubmatch(s)
+		if !markdownSafeLink.MatchString(html.UnescapeString(m[2])) {
+			return m[1]
+		}
+		return aside(`<img src="` + m[2] + `" alt="` + m[1] + `">`)
+	})
+	text = markdownLink.ReplaceAllStringFunc(text, func(s string) string {
+		m := markdownLink.FindStringSubmatch(s)
+		if !markdownSafeLink.MatchString(html.UnescapeString(m[2])) {
+			return m[1]
+		}
+		return aside(`<a href="` + m[2] + `">` + m[1] + `</a>`)
+	})
+	text = markdownStrong.ReplaceAllString(text, "<strong>$2</strong>")
+	text = markdownEm.ReplaceAllString(text, "$1<em>$2</em>")
+
+	// later spans may hold earlier ones so they are put back first.
+	for i := len(spans) - 1; i >= 0; i-- {
+		text = strings.Replace(text, "\x00"+strconv.Itoa(i)+"\x00", spans[i], 1)
+	}
+	return text
+}
@@ -0,0 +1,245 @@
+package app
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mcfriend99/gaga/logger"
+)
+
+// Middleware wraps the next controller in a chain to run code before
+// and after it. A middleware may return without calling next to stop
+// the chain and respond on its own.
+//
+//  Example:
+//
+//  func Auth(next app.Controller) app.Controller {
+//  	return func(r *app.Request) string {
+//  		if r.Header["Authorization"] == "" {
+//  			r.Response.StatusCode = http.StatusUnauthorized
+//  			return "unauthorized"
+//  		}
+//  		return next(r)
+//  	}
+//  }
+type Middleware func(next Controller) Controller
+
+// chain wraps the controller in the middlewares. The first middleware
+// is the outermost one and runs first.
+func chain(controller Controller, middlewares []Middleware) Controller {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		controller = middlewares[i](controller)
+	}
+	return controller
+}
+
+// responseRecorder records the status code and size of a response
+// as it is written.
+type responseRecorder struct {
+	http.ResponseWriter
+	_status int
+	_size   int
+}
+
+func (w *responseRecorder) WriteHeader(code int) {
+	if w._status == 0 {
+		w._status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	if w._status == 0 {
+		w._status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w._size += n
+	return n, err
+}
+
+func (w *responseRecorder) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// AccessLog is a middleware that logs every request once its
+// response has been written.
+func AccessLog(next Controller) Controller {
+	return func(r *Request) string {
+		recorder := &responseRecorder{ResponseWriter: r.Writer}
+		r.Writer = recorder
+
+		r.AfterResponse(func() {
+			status := recorder._status
+			if status == 0 {
+				status = r.Response.StatusCode
+			}
+
+			logMethod := logger.Infof
+			if status < 200 || status >= 399 {
+				logMethod = logger.Warnf
+			}
+			logMethod(`%s "%s %s %s" %d %d %s "%s"`,
+				r.BaseRequest.RemoteAddr,
+				r.BaseRequest.Method,
+				r.BaseRequest.RequestURI,
+				r.BaseRequest.Proto,
+				status,
+				recorder._size,
+				recorder.Header().Get("Content-Type"),
+				r.BaseRequest.UserAgent(),
+			)
+		})
+
+		return next(r)
+	}
+}
+
+// compressWriter compresses a response once it grows past a threshold.
+// Headers are held back until it is known whether the response will be
+// compressed.
+type compressWriter struct {
+	http.ResponseWriter
+	_encoding  string
+	_threshold int
+	_status    int
+	_buffer    []byte
+	_decided   bool
+	_writer    io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w._status == 0 {
+		w._status = code
+	}
+	if code == http.StatusNoContent || code == http.StatusNotModified {
+		w.start(false)
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w._decided {
+		if w._writer != nil {
+			return w._writer.Write(b)
+		}
+		return w.ResponseWriter.Write(b)
+	}
+
+	w._buffer = append(w._buffer, b...)
+	if len(w._buffer) > w._threshold {
+		if err := w.start(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// start sends the held back headers and buffered data.
+func (w *compressWriter) start(compress bool) error {
+	if w._decided {
+		return nil
+	}
+	w._decided = true
+
+	// parts of a file are ranges of its uncompressed bytes.
+	header := w.ResponseWriter.Header()
+	if w._status == http.StatusPartialContent || header.Get("Content-Range") != "" {
+		compress = false
+	}
+	if compress && header.Get("Content-Encoding") == "" {
+		header.Set("Content-Encoding", w._encoding)
+		header.Del("Content-Length")
+
+		if w._encoding == "gzip" {
+			w._writer = gzip.NewWriter(w.ResponseWriter)
+		} else {
+			w._writer, _ = flate.NewWriter(w.ResponseWriter, flate.BestCompression)
+		}
+	}
+
+	if w._status == 0 {
+		w._status = http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w._status)
+
+	if len(w._buffer) == 0 {
+		return nil
+	}
+	buffer := w._buffer
+	w._buffer = nil
+	_, err := w.Write(buffer)
+	return err
+}
+
+// Flush sends whatever has been written so far to the client,
+// compressing it unless it is empty.
+func (w *compressWriter) Flush() {
+	if err := w.start(len(w._buffer) > 0); err != nil {
+		logger.Error("Failed to write response:", err)
+		return
+	}
+	if flusher, ok := w._writer.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Close flushes whatever is left of the response.
+func (w *compressWriter) Close() {
+	if err := w.start(false); err != nil {
+		logger.Error("Failed to write response:", err)
+	}
+	if w._writer != nil {
+		if err := w._writer.Close(); err != nil {
+			logger.Error("Failed to write response:", err)
+		}
+	}
+}
+
+// Compress returns a middleware that compresses responses larger than
+// the configured threshold when the requester supports it.
+// Static files are always compressed.
+func Compress(config SEOConfig) Middleware {
+	return func(next Controller) Controller {
+		if !config.Compress {
+			return next
+		}
+
+		return func(r *Request) string {
+			r.Writer.Header().Add("Vary", "Accept-Encoding")
+
+			// prioritize gzip over deflate
+			encoding := ""
+			if strings.Contains(r.BaseRequest.Header.Get("Accept-Encoding"), "gzip") {
+				encoding = "gzip"
+			} else if strings.Contains(r.BaseRequest.Header.Get("Accept-Encoding"), "deflate") {
+				encoding = "deflate"
+			}
+			// ranges are served from the uncompressed bytes.
+			if encoding == "" || r.BaseRequest.Header.Get("Range") != "" {
+				return next(r)
+			}
+
+			threshold := config.CompressionThreshold
+			if r._route != nil && r._route._isStatic {
+				threshold = 0
+			}
+
+			writer := &compressWriter{
+				ResponseWriter: r.Writer,
+				_encoding:      encoding,
+				_threshold:     threshold,
+			}
+			r.Writer = writer
+			r.AfterResponse(writer.Close)
+
+			return next(r)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package app
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mcfriend99/gaga/logger"
+)
+
+// short names of the media types most responses are offered in.
+var mediaTypeNames = map[string]string{
+	"html": "text/html",
+	"json": "application/json",
+	"xml":  "application/xml",
+	"text": "text/plain",
+}
+
+// media types picked first when a client accepts several equally.
+var mediaTypePreference = []string{
+	"text/html",
+	"application/json",
+	"application/xml",
+	"text/xml",
+	"text/plain",
+}
+
+// acceptRange is a media range of an Accept header.
+type acceptRange struct {
+	typ     string
+	subtype string
+	q       float64
+}
+
+// parseAccept returns the media ranges of an Accept header.
+// A missing header accepts everything.
+func parseAccept(header string) []acceptRange {
+	if strings.TrimSpace(header) == "" {
+		return []acceptRange{{typ: "*", subtype: "*", q: 1}}
+	}
+
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		i := strings.Index(mediaType, "/")
+		if i < 0 {
+			if mediaType != "*" {
+				continue
+			}
+			mediaType, i = "*/*", 1
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+
+		ranges = append(ranges, acceptRange{
+			typ:     mediaType[:i],
+			subtype: mediaType[i+1:],
+			q:       q,
+		})
+	}
+	return ranges
+}
+
+// quality returns the quality the ranges give the media type along with
+// how specific the matching range was, or -1 when no range matches.
+// The most specific matching range decides the quality.
+func quality(ranges []acceptRange, mediaType string) (float64, int) {
+	typ, subtype := mediaType, ""
+	if i := strings.Index(mediaType, "/"); i >= 0 {
+		typ, subtype = mediaType[:i], mediaType[i+1:]
+	}
+
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		s := -1
+		switch {
+		case r.typ == typ && r.subtype == subtype:
+			s = 2
+		case r.typ == typ && r.subtype == "*":
+			s = 1
+		case r.typ == "*" && r.subtype == "*":
+			s = 0
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q, specificity
+}
+
+// negotiate returns the offered media type the Accept header prefers
+// or an empty string if it accepts none of them.
+func negotiate(header string, offers []string) string {
+	ranges := parseAccept(header)
+
+	best, bestQ, bestSpecificity := "", 0.0, -1
+	for _, offer := range offers {
+		q, specificity := quality(ranges, offer)
+		if q <= 0 {
+			continue
+		}
+		if q > bestQ || q == bestQ && specificity > bestSpecificity {
+			best, bestQ, bestSpecificity = offer, q, specificity
+		}
+	}
+	return best
+}
+
+// sortOffers orders media types by preference so that ties in the
+// Accept header are always broken the same way.
+func sortOffers(offers []string) {
+	rank := func(offer string) int {
+		for i, mediaType := range mediaTypePreference {
+			if offer == mediaType {
+				return i
+			}
+		}
+		return len(mediaTypePreference)
+	}
+	sort.Slice(offers, func(i, j int) bool {
+		if rank(offers[i]) != rank(offers[j]) {
+			return rank(offers[i]) < rank(offers[j])
+		}
+		return offers[i] < offers[j]
+	})
+}
+
+// Negotiate responds with the responder of the media type the client
+// prefers according to its Accept header, q-values included. Media types
+// may be given in full or as html, json, xml or text. The content type of
+// the response is set to the chosen media type unless the responder sets
+// another one, and a 406 Not Acceptable error is returned when the client
+// accepts none of them.
+//
+//  Example:
+//
+//  return r.Negotiate(map[string]func() string{
+//  	"html": func() string { return "<h1>" + user.Name + "</h1>" },
+//  	"json": func() string { return r.JSON(user) },
+//  })
+func (r *Request) Negotiate(responders map[string]func() string) string {
+	r.Response.Header.Add("Vary", "Accept")
+
+	byType := make(map[string]func() string, len(responders))
+	offers := make([]string, 0, len(responders))
+	for mediaType, responder := range responders {
+		if full, ok := mediaTypeNames[mediaType]; ok {
+			mediaType = full
+		}
+		byType[mediaType] = responder
+		offers = append(offers, mediaType)
+	}
+	sortOffers(offers)
+
+	mediaType := negotiate(r.BaseRequest.Header.Get("Accept"), offers)
+	if mediaType == "" {
+		return r._app.errorResponse(r, http.StatusNotAcceptable)
+	}
+
+	r.Response.ContentType = mediaType
+	return byType[mediaType]()
+}
+
+// Respond responds with data in the format the client prefers.
+// HTML is the named view rendered with data and is only offered when a
+// view is given, JSON and XML are the encoded data and plain text is
+// the data formatted with fmt. XML is only offered when the data can be
+// encoded as XML, which maps can not.
+//
+//  Example:
+//
+//  func User(r *app.Request) string {
+//  	// ...
+//  	return r.Respond(user, "users/show")
+//  }
+func (r *Request) Respond(data interface{}, view string) string {
+	responders := map[string]func() string{
+		"json": func() string {
+			return r.encoded("JSON", data, json.Marshal)
+		},
+		"text": func() string {
+			return fmt.Sprint(data)
+		},
+	}
+
+	// data is encoded up front so clients that also take other formats
+	// get one of them rather than an error.
+	if b, err := xml.Marshal(data); err == nil {
+		responders["xml"] = func() string {
+			return r.Bytes(r.Response.ContentType, b)
+		}
+	}
+
+	if view != "" {
+		responders["html"] = func() string {
+			return r.View(view, data)
+		}
+	}
+
+	return r.Negotiate(responders)
+}
+
+// encoded responds with data encoded by the encoder in the negotiated
+// content type.
+func (r *Request) encoded(format string, data interface{}, encode func(interface{}) ([]byte, error)) string {
+	b, err := encode(data)
+	if err != nil {
+		logger.Errorf("Failed to encode %s response: %v", format, err)
+		r.Response.ContentType = ""
+		return r._app.errorResponse(r, http.StatusInternalServerError)
+	}
+	return r.Bytes(r.Response.ContentType, b)
+}
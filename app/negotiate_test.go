@@ -0,0 +1,84 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateVary(t *testing.T) {
+	tests := []struct {
+		encoding string
+		vary     []string
+	}{
+		{"", []string{"Accept", "Accept-Encoding"}},
+		{"gzip", []string{"Accept", "Accept-Encoding"}},
+		{"deflate", []string{"Accept", "Accept-Encoding"}},
+	}
+
+	for _, test := range tests {
+		g := &Gaga{Config: &Config{}, RouteGenerator: func(r *Routing) {
+			r.Get("/neg", func(r *Request) string {
+				return r.Negotiate(map[string]func() string{
+					"html": func() string { return strings.Repeat("<p>gaga</p>", 100) },
+					"json": func() string { return r.JSON("gaga") },
+				})
+			})
+		}}
+		g.Use(Compress(SEOConfig{Compress: true}))
+
+		req := httptest.NewRequest(http.MethodGet, "/neg", nil)
+		req.Header.Set("Accept", "text/html")
+		if test.encoding != "" {
+			req.Header.Set("Accept-Encoding", test.encoding)
+		}
+		w := httptest.NewRecorder()
+		g.ServeHTTP(w, req)
+
+		if encoding := w.Header().Get("Content-Encoding"); encoding != test.encoding {
+			t.Errorf("%q: Content-Encoding = %q, want %q", test.encoding, encoding, test.encoding)
+		}
+
+		vary := w.Header()["Vary"]
+		if len(vary) != len(test.vary) {
+			t.Errorf("%q: Vary = %q, want %q", test.encoding, vary, test.vary)
+			continue
+		}
+		for _, name := range test.vary {
+			found := false
+			for _, v := range vary {
+				found = found || v == name
+			}
+			if !found {
+				t.Errorf("%q: Vary = %q, missing %q", test.encoding, vary, name)
+			}
+		}
+	}
+}
+
+func TestAddVary(t *testing.T) {
+	tests := []struct {
+		existing []string
+		value    string
+		vary     []string
+	}{
+		{nil, "Accept", []string{"Accept"}},
+		{[]string{"Accept-Encoding"}, "Accept", []string{"Accept-Encoding", "Accept"}},
+		{[]string{"Accept"}, "accept", []string{"Accept"}},
+		{[]string{"Accept-Encoding, Accept"}, "Accept", []string{"Accept-Encoding, Accept"}},
+		{[]string{"Accept-Encoding"}, "Accept, Cookie", []string{"Accept-Encoding", "Accept", "Cookie"}},
+	}
+
+	for _, test := range tests {
+		header := http.Header{}
+		for _, v := range test.existing {
+			header.Add("Vary", v)
+		}
+		addVary(header, test.value)
+
+		if strings.Join(header["Vary"], "|") != strings.Join(test.vary, "|") {
+			t.Errorf("addVary(%q, %q) = %q, want %q", test.existing, test.value, header["Vary"], test.vary)
+		}
+	}
+}
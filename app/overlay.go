@@ -0,0 +1,84 @@
+package app
+
+import (
+	"bytes"
+	"html/template"
+	"io/ioutil"
+	"strings"
+
+	"github.com/mcfriend99/gaga/logger"
+)
+
+// lines of source shown on either side of the line of an error.
+const overlayContext = 4
+
+// overlayLine is a line of the source excerpt of an error.
+type overlayLine struct {
+	Number int
+	Text   string
+	Failed bool
+}
+
+// overlayTemplate is the page errors in views are shown with in dev mode.
+var overlayTemplate = template.Must(template.New("overlay").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>View error</title>
+<style>
+body { margin: 0; background: #1e1e24; color: #e8e8ea; font: 15px/1.5 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; }
+main { max-width: 960px; margin: 48px auto; padding: 0 24px; }
+h1 { margin: 0 0 8px; color: #ff6b6b; font-size: 22px; }
+.file { color: #9a9aa5; font-family: Menlo, Consolas, monospace; }
+.message { margin: 24px 0; padding: 16px; background: #2a1d22; border-left: 4px solid #ff6b6b; white-space: pre-wrap; font-family: Menlo, Consolas, monospace; }
+pre { margin: 0; padding: 16px 0; background: #16161b; border-radius: 6px; overflow-x: auto; font: 13px/1.6 Menlo, Consolas, monospace; }
+.line { display: block; padding: 0 16px; }
+.line.failed { background: #4a1f26; }
+.number { display: inline-block; width: 48px; color: #6b6b78; user-select: none; }
+</style>
+</head>
+<body>
+<main>
+<h1>Failed to render view {{ .View }}</h1>
+<div class="file">{{ .File }}{{ if .Line }}:{{ .Line }}{{ end }}</div>
+<div class="message">{{ .Message }}</div>
+{{ if .Lines }}<pre>{{ range .Lines }}<span class="line{{ if .Failed }} failed{{ end }}"><span class="number">{{ .Number }}</span>{{ .Text }}</span>{{ end }}</pre>{{ end }}
+</main>
+</body>
+</html>
+`))
+
+// overlay returns an HTML page showing the error along with the lines
+// of the view around it.
+func (e *ViewError) overlay() string {
+	var lines []overlayLine
+	if e.Line > 0 {
+		if b, err := ioutil.ReadFile(e.File); err == nil {
+			source := strings.Split(strings.TrimRight(string(b), "\n"), "\n")
+			for i := e.Line - overlayContext; i <= e.Line+overlayContext; i++ {
+				if i < 1 || i > len(source) {
+					continue
+				}
+				lines = append(lines, overlayLine{
+					Number: i,
+					Text:   source[i-1],
+					Failed: i == e.Line,
+				})
+			}
+		}
+	}
+
+	var buffer bytes.Buffer
+	err := overlayTemplate.Execute(&buffer, map[string]interface{}{
+		"View":    e.View,
+		"File":    e.File,
+		"Line":    e.Line,
+		"Message": e.Err.Error(),
+		"Lines":   lines,
+	})
+	if err != nil {
+		logger.Error("Failed to render view error:", err)
+		return template.HTMLEscapeString(e.Error())
+	}
+	return buffer.String()
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UUID is a parsed universally unique identifier.
+type UUID [16]byte
+
+// String returns the UUID in its canonical 8-4-4-4-12 hex form.
+func (u UUID) String() string {
+	s := hex.EncodeToString(u[:])
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+func parseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, fmt.Errorf("invalid UUID %q", s)
+	}
+	b, err := hex.DecodeString(strings.Replace(s, "-", "", -1))
+	if err != nil {
+		return u, fmt.Errorf("invalid UUID %q", s)
+	}
+	copy(u[:], b)
+	return u, nil
+}
+
+// param returns the named route param or an error if it is missing.
+func (r *Request) param(name string) (string, error) {
+	value, ok := r.Params[name]
+	if !ok || value == "" {
+		return "", fmt.Errorf("route param %q is missing", name)
+	}
+	return value, nil
+}
+
+// ParamInt returns the named route param as an int.
+func (r *Request) ParamInt(name string) (int, error) {
+	value, err := r.param(name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(value)
+}
+
+// ParamInt64 returns the named route param as an int64.
+func (r *Request) ParamInt64(name string) (int64, error) {
+	value, err := r.param(name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// ParamFloat returns the named route param as a float64.
+func (r *Request) ParamFloat(name string) (float64, error) {
+	value, err := r.param(name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// ParamBool returns the named route param as a bool.
+func (r *Request) ParamBool(name string) (bool, error) {
+	value, err := r.param(name)
+	if err != nil {
+		return false, err
+	}
+	return strconv.ParseBool(value)
+}
+
+// ParamUUID returns the named route param as a UUID.
+func (r *Request) ParamUUID(name string) (UUID, error) {
+	value, err := r.param(name)
+	if err != nil {
+		return UUID{}, err
+	}
+	return parseUUID(value)
+}
+
+// ParamDate returns the named route param as a date in the
+// YYYY-MM-DD form.
+func (r *Request) ParamDate(name string) (time.Time, error) {
+	value, err := r.param(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(dateLayout, value)
+}
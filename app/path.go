@@ -0,0 +1,45 @@
+package app
+
+import (
+	"net/url"
+	"strings"
+)
+
+// cleanPath breaks the escaped path of a request into its decoded
+// segments. Empty segments from duplicate or trailing slashes and dot
+// segments are resolved the way path.Clean does. Encoded slashes stay
+// within their segment.
+//
+// It also returns the canonical escaped form of the path, which keeps
+// a trailing slash when the path has one.
+func cleanPath(escaped string) ([]string, string, error) {
+	var parts, raw []string
+	for _, s := range strings.Split(escaped, "/") {
+		if s == "" {
+			continue
+		}
+
+		part, err := url.PathUnescape(s)
+		if err != nil {
+			return nil, "", err
+		}
+
+		switch part {
+		case ".":
+		case "..":
+			if len(parts) > 0 {
+				parts = parts[:len(parts)-1]
+				raw = raw[:len(raw)-1]
+			}
+		default:
+			parts = append(parts, part)
+			raw = append(raw, s)
+		}
+	}
+
+	canonical := "/" + strings.Join(raw, "/")
+	if len(raw) > 0 && strings.HasSuffix(escaped, "/") {
+		canonical += "/"
+	}
+	return parts, canonical, nil
+}
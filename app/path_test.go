@@ -0,0 +1,138 @@
+package app
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mcfriend99/gaga/logger"
+)
+
+func init() {
+	logger.Init(&logger.Config{LogDest: logger.LogDestNone})
+}
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		escaped   string
+		parts     []string
+		canonical string
+	}{
+		{"/", nil, "/"},
+		{"", nil, "/"},
+		{"/users", []string{"users"}, "/users"},
+		{"/users/", []string{"users"}, "/users/"},
+		{"//users//42", []string{"users", "42"}, "/users/42"},
+		{"/users/./42", []string{"users", "42"}, "/users/42"},
+		{"/users/../posts", []string{"posts"}, "/posts"},
+		{"/../../users", []string{"users"}, "/users"},
+		{"/a/b/../../", nil, "/"},
+		{"/files/a%2Fb", []string{"files", "a/b"}, "/files/a%2Fb"},
+		{"/files/%2E%2E/x", []string{"x"}, "/x"},
+		{"/caf%C3%A9", []string{"café"}, "/caf%C3%A9"},
+	}
+
+	for _, test := range tests {
+		parts, canonical, err := cleanPath(test.escaped)
+		if err != nil {
+			t.Errorf("cleanPath(%q) failed: %v", test.escaped, err)
+			continue
+		}
+		if len(parts) == 0 {
+			parts = nil
+		}
+		if !reflect.DeepEqual(parts, test.parts) {
+			t.Errorf("cleanPath(%q) parts = %q, want %q", test.escaped, parts, test.parts)
+		}
+		if canonical != test.canonical {
+			t.Errorf("cleanPath(%q) canonical = %q, want %q", test.escaped, canonical, test.canonical)
+		}
+	}
+
+	if _, _, err := cleanPath("/bad%zz"); err == nil {
+		t.Errorf("cleanPath(%q) did not fail", "/bad%zz")
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gaga")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(filepath.Join(dir, "a"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "a", "b"), []byte("b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	routes := func(r *Routing) {
+		r.Get("/users", func(r *Request) string { return "users " + r.Path })
+		r.Get("/users/{id}", func(r *Request) string { return "user " + r.Params["id"] })
+		r.Get("/search", func(r *Request) string { return "search " + r.Get("q").(string) })
+		r.Static("/static/", dir)
+	}
+
+	tests := []struct {
+		cleanPath     string
+		trailingSlash string
+		target        string
+		code          int
+		body          string
+		location      string
+	}{
+		{"", "", "/users", 200, "users /users", ""},
+		{"", "", "/users?page=2", 200, "users /users", ""},
+		{"", "", "/users/", 200, "users /users", ""},
+		{"", "", "//users", 200, "users /users", ""},
+		{"", "", "/x/../users", 200, "users /users", ""},
+		{"", "", "/./users/.", 200, "users /users", ""},
+		{"", "", "/users/a%2Fb", 200, "user a/b", ""},
+		{"", "", "/users/a/b", 404, "", ""},
+		{"", "", "/search?q=a%2Fb", 200, "search a/b", ""},
+		{"", "", "/static/a/b", 200, "b", ""},
+		{"", "", "/static/a%2Fb", 404, "", ""},
+
+		{"match", "redirect", "/users/", 301, "", "/users"},
+		{"match", "redirect", "/users/?page=2", 301, "", "/users?page=2"},
+		{"match", "redirect", "/users", 200, "users /users", ""},
+		{"match", "redirect", "/", 404, "", ""},
+		{"match", "redirect", "/static/a/", 404, "", ""},
+		{"match", "redirect", "/missing/", 404, "", ""},
+		{"match", "match", "//users", 200, "users /users", ""},
+
+		{"redirect", "", "//users", 301, "", "/users"},
+		{"redirect", "", "/x/../users?page=2", 301, "", "/users?page=2"},
+		{"redirect", "", "/users/./42", 301, "", "/users/42"},
+		{"redirect", "", "/users/a%2Fb", 200, "user a/b", ""},
+		{"redirect", "", "/users/", 200, "users /users", ""},
+		{"redirect", "", "/users", 200, "users /users", ""},
+		{"redirect", "redirect", "//users/", 301, "", "/users/"},
+		{"redirect", "redirect", "/users/", 301, "", "/users"},
+	}
+
+	for _, test := range tests {
+		g := &Gaga{Config: &Config{}, RouteGenerator: routes}
+		g.Config.Server.CleanPath = test.cleanPath
+		g.Config.Server.TrailingSlash = test.trailingSlash
+
+		w := httptest.NewRecorder()
+		g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.target, nil))
+
+		name := test.cleanPath + "/" + test.trailingSlash + " " + test.target
+		if w.Code != test.code {
+			t.Errorf("%s: code = %d, want %d", name, w.Code, test.code)
+		}
+		if test.body != "" && w.Body.String() != test.body {
+			t.Errorf("%s: body = %q, want %q", name, w.Body.String(), test.body)
+		}
+		if location := w.Header().Get("Location"); location != test.location {
+			t.Errorf("%s: location = %q, want %q", name, location, test.location)
+		}
+	}
+}
@@ -1,17 +1,19 @@
 package app
 
-import "net/http"
-
-// Response struct
-type Response struct {
-	Header      map[string]string
-	StatusCode  int
-	ContentType string
-}
+import (
+	"net/http"
+	"net/url"
+)
 
 // Request struct
+//
+// URI is the raw request URI as sent by the client while Path is its
+// cleaned and decoded path that routes are matched against. Like
+// URL.Path, encoded slashes are decoded in Path, while routes match
+// them within their segment.
 type Request struct {
 	URI         string
+	Path        string
 	Method      string
 	Header      map[string]string
 	Params      map[string]string
@@ -20,26 +22,57 @@ type Request struct {
 	BaseRequest *http.Request
 
 	// internal items...
-	_getsData  map[string]interface{}
-	_postsData map[string]interface{}
+	_getsData  url.Values
+	_postsData url.Values
+	_jsonData  map[string]interface{}
 	_filesData map[string]interface{}
+
+	_app           *Gaga
+	_route         *Route
+	_escapedPath   string
+	_afterResponse []func()
+	_session       *Session
+	_flashIn       map[string]interface{}
+	_flashOut      map[string]interface{}
+	_inErrorPage   bool
+
+	_bodyParsed bool
+	_bodyStatus int
+	_body       []byte
+	_bodyRead   bool
 }
 
+// AfterResponse registers a function to run once the response has been
+// written. Functions run in the reverse order of their registration.
+func (r *Request) AfterResponse(fn func()) {
+	r._afterResponse = append(r._afterResponse, fn)
+}
+
+// Get returns the first value of the named query string parameter
+// or nil if it is missing. See GetAll for all its values.
 func (r *Request) Get(name string) interface{} {
-	if val, ok := r._getsData[name]; ok {
-		return val
+	if val, ok := r._getsData[name]; ok && len(val) > 0 {
+		return val[0]
 	}
 	return nil
 }
 
+// Post returns the first value of the named form field or the value
+// of the named top level key of a JSON body, or nil if it is missing.
+// See PostAll for all the values of a field.
 func (r *Request) Post(name string) interface{} {
-	if val, ok := r._postsData[name]; ok {
+	r.parseBody()
+	if val, ok := r._postsData[name]; ok && len(val) > 0 {
+		return val[0]
+	}
+	if val, ok := r._jsonData[name]; ok {
 		return val
 	}
 	return nil
 }
 
 func (r *Request) File(name string) interface{} {
+	r.parseBody()
 	if val, ok := r._filesData[name]; ok {
 		return val
 	}
@@ -0,0 +1,120 @@
+package app
+
+import "strings"
+
+// Resource actions in the order their routes are created.
+const (
+	ActionIndex   = "index"
+	ActionNew     = "new"
+	ActionStore   = "store"
+	ActionShow    = "show"
+	ActionEdit    = "edit"
+	ActionUpdate  = "update"
+	ActionDestroy = "destroy"
+)
+
+type resourceOptions struct {
+	_only   map[string]bool
+	_except map[string]bool
+	_param  string
+}
+
+// ResourceOption customizes the routes created by Routing.Resource.
+type ResourceOption func(*resourceOptions)
+
+// Only limits the routes of a resource to the given actions.
+func Only(actions ...string) ResourceOption {
+	return func(o *resourceOptions) {
+		o._only = make(map[string]bool)
+		for _, action := range actions {
+			o._only[action] = true
+		}
+	}
+}
+
+// Except leaves the given actions out of the routes of a resource.
+func Except(actions ...string) ResourceOption {
+	return func(o *resourceOptions) {
+		for _, action := range actions {
+			o._except[action] = true
+		}
+	}
+}
+
+// IDParam sets the name of the param identifying an item of a
+// resource. It defaults to id.
+func IDParam(name string) ResourceOption {
+	return func(o *resourceOptions) {
+		o._param = name
+	}
+}
+
+func (o *resourceOptions) allows(action string) bool {
+	if o._only != nil && !o._only[action] {
+		return false
+	}
+	return !o._except[action]
+}
+
+// Resource creates the conventional RESTful routes for a resource
+// under the path and returns the group holding them.
+// The routes are named after the static parts of the path and the
+// action, e.g. users.photos.show for /users/{user}/photos/{id}.
+//
+//  GET       /photos            List    (index, requires ResourceLister)
+//  GET       /photos/new        New     (new, requires ResourceNewer)
+//  POST      /photos            Create  (store)
+//  GET       /photos/{id}       View    (show)
+//  GET       /photos/{id}/edit  Edit    (edit, requires ResourceEditor)
+//  PUT/PATCH /photos/{id}       Update  (update)
+//  DELETE    /photos/{id}       Delete  (destroy)
+//
+//  Resources are nested by using the params of the parent in the path.
+//
+//  Example:
+//
+//  r.Resource("/photos", controller.Photo{}, app.Except(app.ActionDestroy)).Use(Auth)
+//  r.Resource("/users/{user}/photos", controller.UserPhoto{}, app.IDParam("photo"))
+func (r *Routing) Resource(path string, resource Resource, options ...ResourceOption) *Routing {
+	o := &resourceOptions{_except: make(map[string]bool), _param: "id"}
+	for _, option := range options {
+		option(o)
+	}
+
+	item := "/{" + o._param + "}"
+
+	var base []string
+	for _, s := range parsePattern(path) {
+		if s.kind == segmentStatic {
+			base = append(base, s.value)
+		}
+	}
+	name := func(action string) string {
+		return strings.Join(append(base, action), ".")
+	}
+
+	return r.Group(path, func(g *Routing) {
+		if lister, ok := resource.(ResourceLister); ok && o.allows(ActionIndex) {
+			g.Get("/", lister.List).Name(name(ActionIndex))
+		}
+		if newer, ok := resource.(ResourceNewer); ok && o.allows(ActionNew) {
+			g.Get("/new", newer.New).Name(name(ActionNew))
+		}
+		if o.allows(ActionStore) {
+			g.Post("/", resource.Create).Name(name(ActionStore))
+		}
+		if o.allows(ActionShow) {
+			g.Get(item, resource.View).Name(name(ActionShow))
+		}
+		if editor, ok := resource.(ResourceEditor); ok && o.allows(ActionEdit) {
+			g.Get(item+"/edit", editor.Edit).Name(name(ActionEdit))
+		}
+		if o.allows(ActionUpdate) {
+			g.Put(item, resource.Update).Name(name(ActionUpdate))
+			g.Patch(item, resource.Update)
+		}
+		if o.allows(ActionDestroy) {
+			g.Delete(item, resource.Delete).Name(name(ActionDestroy))
+		}
+	})
+}
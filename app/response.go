@@ -0,0 +1,147 @@
+package app
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mcfriend99/gaga/logger"
+)
+
+// kinds of response bodies.
+const (
+	bodyString = iota
+	bodyBytes
+	bodyFile
+	bodyStream
+)
+
+// Response struct
+//
+// By default the string returned by a controller is the body of the
+// response. The body helpers on Request such as JSON and SendFile
+// replace it with another kind of body.
+type Response struct {
+	Header      http.Header
+	StatusCode  int
+	ContentType string
+
+	_kind    int
+	_bytes   []byte
+	_file    *os.File
+	_modTime time.Time
+	_reader  io.Reader
+}
+
+// JSON responds with v encoded as JSON.
+//
+//  Example:
+//
+//  func User(r *app.Request) string {
+//  	return r.JSON(map[string]interface{}{"id": 1, "name": "Gaga"})
+//  }
+func (r *Request) JSON(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		logger.Error("Failed to encode JSON response:", err)
+		return r._app.errorResponse(r, http.StatusInternalServerError)
+	}
+	return r.Bytes("application/json; charset=utf-8", b)
+}
+
+// Bytes responds with the bytes as a body of the given content type.
+func (r *Request) Bytes(contentType string, b []byte) string {
+	r.Response.ContentType = contentType
+	r.Response._kind = bodyBytes
+	r.Response._bytes = b
+	return ""
+}
+
+// Redirect responds with a redirect to the url. The code defaults to
+// 302 Found when it is not a redirect status.
+func (r *Request) Redirect(url string, code int) string {
+	if code < 300 || code > 399 {
+		code = http.StatusFound
+	}
+	r.Response.Header.Set("Location", url)
+	r.Response.StatusCode = code
+	return ""
+}
+
+// SendFile responds with the content of the file at path.
+// Range and conditional requests are handled and the content type is
+// guessed from the file extension unless set on the response.
+func (r *Request) SendFile(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return r._app.errorResponse(r, http.StatusNotFound)
+	}
+
+	stat, err := f.Stat()
+	if err != nil || stat.IsDir() {
+		f.Close()
+		return r._app.errorResponse(r, http.StatusNotFound)
+	}
+
+	r.Response._kind = bodyFile
+	r.Response._file = f
+	r.Response._modTime = stat.ModTime()
+	r.AfterResponse(func() {
+		f.Close()
+	})
+	return ""
+}
+
+// Stream responds with everything read from the reader, flushing it
+// to the client as it goes. The content type defaults to
+// application/octet-stream and the reader is closed when done if it
+// is an io.Closer.
+func (r *Request) Stream(reader io.Reader) string {
+	if r.Response.ContentType == "" {
+		r.Response.ContentType = "application/octet-stream"
+	}
+	r.Response._kind = bodyStream
+	r.Response._reader = reader
+	if closer, ok := reader.(io.Closer); ok {
+		r.AfterResponse(func() {
+			closer.Close()
+		})
+	}
+	return ""
+}
+
+// bodyAllowed reports whether a response with the status may have a body.
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// stream copies the reader to the writer, flushing after every chunk.
+func stream(w http.ResponseWriter, reader io.Reader) error {
+	flusher, _ := w.(http.Flusher)
+	buffer := make([]byte, 32*1024)
+	for {
+		n, err := reader.Read(buffer)
+		if n > 0 {
+			if _, err := w.Write(buffer[:n]); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// fileName returns the name of the file a response is sending.
+func (r *Response) fileName() string {
+	return filepath.Base(r._file.Name())
+}
@@ -1,16 +1,34 @@
 package app
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Route struct
+//
+// The path of a route may contain named params such as /users/{id},
+// optional params such as /posts/{page?}, typed params such as
+// /users/{id:int} and a catch-all param capturing the rest of the
+// path, slashes included, such as /docs/{path*}. The type of a
+// catch-all param such as /docs/{path*:alpha} applies to all it captures.
 type Route struct {
 	Path             string
 	Controller       Controller
 	_isStatic        bool
 	_paramValidators map[string]string
 	_paramDefaults   map[string]string
+	_validators      map[string]func(string) bool
+	_middlewares     []Middleware
+	_group           *Routing
+	_handler         Controller
+	_name            string
+	_domainGroup     *Routing
+	_dir             string
 }
 
-func _newRoute(path string, controller Controller) Route {
-	return Route{
+func _newRoute(path string, controller Controller) *Route {
+	return &Route{
 		Path:             path,
 		Controller:       controller,
 		_paramValidators: make(map[string]string),
@@ -24,6 +42,8 @@ func _newRoute(path string, controller Controller) Route {
 //  Example:
 //
 //  r.Route("/{id}", controller.User).Where("id", `\d+`)
+//
+//  The pattern must match the whole value of the param.
 func (r *Route) Where(name string, test string) *Route {
 	r._paramValidators[name] = test
 	return r
@@ -40,23 +60,197 @@ func (r *Route) Default(name string, test string) *Route {
 	return r
 }
 
+// Use attaches middlewares that run only for this route.
+//
+//  Example:
+//
+//  r.Get("/account", controller.Account).Use(Auth)
+func (r *Route) Use(middlewares ...Middleware) *Route {
+	r._middlewares = append(r._middlewares, middlewares...)
+	return r
+}
+
+// Name gives the route a name that can be used to generate
+// URLs to it with URL.
+//
+//  Example:
+//
+//  r.Get("/users/{id}/edit", controller.EditUser).Name("user.edit")
+func (r *Route) Name(name string) *Route {
+	r._name = name
+	return r
+}
+
+// compile prepares the route's param validators and middleware chain
+// for matching. Validators, defaults and middlewares of the groups the
+// route belongs to are applied from the outermost group in.
+func (r *Route) compile() {
+	var groups []*Routing
+	for group := r._group; group != nil; group = group._parent {
+		groups = append([]*Routing{group}, groups...)
+	}
+
+	validators := make(map[string]string)
+	defaults := make(map[string]string)
+	var middlewares []Middleware
+	for _, group := range groups {
+		if group._domain != "" {
+			r._domainGroup = group
+		}
+		for name, test := range group._paramValidators {
+			validators[name] = test
+		}
+		for name, value := range group._paramDefaults {
+			defaults[name] = value
+		}
+		middlewares = append(middlewares, group._middlewares...)
+	}
+
+	for name, test := range r._paramValidators {
+		validators[name] = test
+	}
+	for name, value := range defaults {
+		if _, ok := r._paramDefaults[name]; !ok {
+			r._paramDefaults[name] = value
+		}
+	}
+
+	r._validators = make(map[string]func(string) bool)
+	for name, test := range validators {
+		r._validators[name] = compileValidator(test)
+	}
+
+	// inline param types must hold along with the validators. Catch-all
+	// params are checked as a whole, slashes included.
+	for _, s := range parsePattern(r.Path) {
+		if (s.kind != segmentParam && s.kind != segmentCatchAll) || s.constraint == "" {
+			continue
+		}
+
+		constraint, ok := r._group.constraint(s.constraint)
+		if !ok {
+			panic(fmt.Sprintf("unknown param type %q in route %s", s.constraint, r.Path))
+		}
+		if test, ok := r._validators[s.value]; ok {
+			r._validators[s.value] = func(value string) bool {
+				return constraint(value) && test(value)
+			}
+		} else {
+			r._validators[s.value] = constraint
+		}
+	}
+
+	// form and JSON bodies are parsed right before the controller runs
+	// so middlewares can turn requests away without reading them.
+	controller := r.Controller
+	handler := func(request *Request) string {
+		if status := request.parseBody(); status != 0 {
+			return request._app.errorResponse(request, status)
+		}
+		if controller == nil {
+			return ""
+		}
+		return controller(request)
+	}
+	r._handler = chain(handler, append(middlewares, r._middlewares...))
+}
+
+// accepts reports whether the captured param values satisfy
+// the route's validators.
+func (r *Route) accepts(names []string, values []string) bool {
+	for i, name := range names {
+		if test, ok := r._validators[name]; ok && values[i] != "" && !test(values[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 // Routing struct
 type Routing struct {
-	Routes          map[string][]Route
-	_shouldCompress bool
+	Routes           map[string][]*Route
+	_prefix          string
+	_parent          *Routing
+	_middlewares     []Middleware
+	_paramValidators map[string]string
+	_paramDefaults   map[string]string
+	_constraints     map[string]func(string) bool
+	_domain          string
+	_domains         []*Routing
+}
+
+func _newRouting(routes map[string][]*Route) *Routing {
+	return &Routing{
+		Routes:           routes,
+		_paramValidators: make(map[string]string),
+		_paramDefaults:   make(map[string]string),
+		_constraints:     make(map[string]func(string) bool),
+	}
+}
+
+// Use attaches middlewares that run for every route in the routing.
+func (r *Routing) Use(middlewares ...Middleware) *Routing {
+	r._middlewares = append(r._middlewares, middlewares...)
+	return r
+}
+
+// Where allows specifying a pattern that a named param must match in
+// every route of the routing. Routes may override it with Route.Where.
+func (r *Routing) Where(name string, test string) *Routing {
+	r._paramValidators[name] = test
+	return r
+}
+
+// Default allows setting a default value to an optional named param
+// in every route of the routing. Routes may override it with Route.Default.
+func (r *Routing) Default(name string, value string) *Routing {
+	r._paramDefaults[name] = value
+	return r
+}
+
+// Group creates a group of routes sharing the path prefix as well as
+// the middlewares, validators and defaults of the group.
+// Groups may be nested in other groups.
+//
+//  Example:
+//
+//  r.Group("/admin", func(g *app.Routing) {
+//  	g.Use(Auth)
+//  	g.Get("/users/{id}", controller.AdminUser)
+//  }).Where("id", `\d+`)
+func (r *Routing) Group(prefix string, routes func(*Routing)) *Routing {
+	group := _newRouting(r.Routes)
+	group._prefix = joinPath(r._prefix, prefix)
+	group._parent = r
+
+	routes(group)
+	return group
+}
+
+// joinPath appends path to the prefix of a group.
+func joinPath(prefix string, path string) string {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if path == "" || path == "/" {
+		if prefix == "" {
+			return "/"
+		}
+		return prefix
+	}
+	return prefix + "/" + strings.TrimPrefix(path, "/")
 }
 
 // CreateRoute allows you to create a route for any HTTP method
 // bound to a given path.
 func (r *Routing) CreateRoute(method string, path string, controller Controller) *Route {
 	if r.Routes[method] == nil {
-		r.Routes[method] = make([]Route, 0)
+		r.Routes[method] = make([]*Route, 0)
 	}
 
-	route := _newRoute(path, controller)
+	route := _newRoute(joinPath(r._prefix, path), controller)
+	route._group = r
 	r.Routes[method] = append(r.Routes[method], route)
 
-	return &route
+	return route
 }
 
 // Get routes an HTTP GET request with a request URI matching
@@ -104,20 +298,23 @@ func (r *Routing) Options(path string, controller Controller) *Route {
 // Head routes an HTTP HEAD request with a request URI matching
 // the given path to the given controller.
 func (r *Routing) Head(path string, controller Controller) *Route {
-	return r.CreateRoute("OPTIONS", path, controller)
+	return r.CreateRoute("HEAD", path, controller)
 }
 
 // Static routes a request for static files matching the path to
 // the specified directory
 func (r *Routing) Static(path string, dir string) {
 	if r.Routes["GET"] == nil {
-		r.Routes["GET"] = make([]Route, 0)
+		r.Routes["GET"] = make([]*Route, 0)
 	}
 
+	path = joinPath(r._prefix, path)
 	route := _newRoute(path, func(h *Request) string {
-		return StaticFileController(h, path, dir, r._shouldCompress)
+		return StaticFileController(h, path, dir)
 	})
 	route._isStatic = true
+	route._dir = dir
+	route._group = r
 
 	r.Routes["GET"] = append(r.Routes["GET"], route)
 }
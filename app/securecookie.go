@@ -0,0 +1,271 @@
+package app
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mcfriend99/gaga/logger"
+)
+
+// errors returned when reading signed and encrypted cookie values.
+var (
+	ErrCookieInvalid = errors.New("cookie value is invalid or was tampered with")
+	ErrCookieExpired = errors.New("cookie value has expired")
+)
+
+// cookieKey holds the keys derived from one app secret.
+type cookieKey struct {
+	_sign    []byte
+	_encrypt cipher.AEAD
+}
+
+// CookieCodec signs and encrypts cookie values with keys derived
+// from app secrets.
+//
+// Values are always signed and encrypted with the first secret while
+// all secrets are tried when reading them, so cookies made before a
+// secret was rotated keep working as long as the old secret is kept.
+// The name of the cookie is part of the signature so a value can not
+// be moved to another cookie.
+type CookieCodec struct {
+	_keys []cookieKey
+}
+
+// NewCookieCodec creates a CookieCodec from the secrets, the current
+// one first. It panics when no secret is given.
+func NewCookieCodec(secrets ...string) *CookieCodec {
+	if len(secrets) == 0 {
+		panic("cookie codec needs at least one secret")
+	}
+
+	c := &CookieCodec{}
+	for _, secret := range secrets {
+		block, err := aes.NewCipher(deriveKey(secret, "encrypt"))
+		if err != nil {
+			panic(err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			panic(err)
+		}
+
+		c._keys = append(c._keys, cookieKey{
+			_sign:    deriveKey(secret, "sign"),
+			_encrypt: aead,
+		})
+	}
+	return c
+}
+
+// deriveKey derives a 256 bit key for the purpose from the secret so
+// the same secret is never used directly for both signing and encryption.
+func deriveKey(secret string, purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("gaga cookie " + purpose))
+	return mac.Sum(nil)
+}
+
+// expiry returns the unix time after which a value with the max age
+// expires or 0 if it never does.
+func expiry(maxAge time.Duration) int64 {
+	if maxAge <= 0 {
+		return 0
+	}
+	return time.Now().Add(maxAge).Unix()
+}
+
+// expired reports whether a value with the expiry has expired.
+func expired(expires int64) bool {
+	return expires != 0 && time.Now().Unix() > expires
+}
+
+// Sign returns the value along with its signature and expiry.
+// The value can be read by anyone but not changed.
+// A max age of zero or less means the value never expires.
+func (c *CookieCodec) Sign(name string, value string, maxAge time.Duration) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(value)) + "." +
+		strconv.FormatInt(expiry(maxAge), 10)
+	return payload + "." + c.signature(c._keys[0], name, payload)
+}
+
+func (c *CookieCodec) signature(key cookieKey, name string, payload string) string {
+	mac := hmac.New(sha256.New, key._sign)
+	mac.Write([]byte(name + "|" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns the value of a signed cookie value made by Sign.
+// It fails with ErrCookieInvalid when the signature does not match
+// any of the secrets and ErrCookieExpired when the value expired.
+func (c *CookieCodec) Verify(name string, signed string) (string, error) {
+	i := strings.LastIndex(signed, ".")
+	if i < 0 {
+		return "", ErrCookieInvalid
+	}
+	payload, signature := signed[:i], signed[i+1:]
+
+	valid := false
+	for _, key := range c._keys {
+		if hmac.Equal([]byte(c.signature(key, name, payload)), []byte(signature)) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return "", ErrCookieInvalid
+	}
+
+	parts := strings.Split(payload, ".")
+	if len(parts) != 2 {
+		return "", ErrCookieInvalid
+	}
+	value, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", ErrCookieInvalid
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", ErrCookieInvalid
+	}
+	if expired(expires) {
+		return "", ErrCookieExpired
+	}
+
+	return string(value), nil
+}
+
+// Encrypt returns the value encrypted along with its expiry.
+// The value can neither be read nor changed without the secret.
+// A max age of zero or less means the value never expires.
+func (c *CookieCodec) Encrypt(name string, value string, maxAge time.Duration) (string, error) {
+	key := c._keys[0]
+
+	nonce := make([]byte, key._encrypt.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	plain := make([]byte, 8, 8+len(value))
+	binary.BigEndian.PutUint64(plain, uint64(expiry(maxAge)))
+	plain = append(plain, value...)
+
+	sealed := key._encrypt.Seal(nonce, nonce, plain, []byte(name))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the value of an encrypted cookie value made by Encrypt.
+// It fails with ErrCookieInvalid when the value can not be decrypted
+// with any of the secrets and ErrCookieExpired when the value expired.
+func (c *CookieCodec) Decrypt(name string, encrypted string) (string, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", ErrCookieInvalid
+	}
+
+	for _, key := range c._keys {
+		size := key._encrypt.NonceSize()
+		if len(sealed) < size {
+			return "", ErrCookieInvalid
+		}
+
+		plain, err := key._encrypt.Open(nil, sealed[:size], sealed[size:], []byte(name))
+		if err != nil || len(plain) < 8 {
+			continue
+		}
+		if expired(int64(binary.BigEndian.Uint64(plain))) {
+			return "", ErrCookieExpired
+		}
+		return string(plain[8:]), nil
+	}
+	return "", ErrCookieInvalid
+}
+
+// cookieCodec returns the cookie codec of the app, creating it from
+// the app secrets the first time it is used.
+func (g *Gaga) cookieCodec() *CookieCodec {
+	g._cookiesOnce.Do(func() {
+		secret := g.Config.App.Secret
+		if secret == "" {
+			logger.Warn("No app secret configured, signed cookies and sessions will not survive restarts.")
+
+			b := make([]byte, 32)
+			if _, err := rand.Read(b); err != nil {
+				panic(err)
+			}
+			secret = string(b)
+		}
+		g._cookies = NewCookieCodec(append([]string{secret}, g.Config.App.PreviousSecrets...)...)
+	})
+	return g._cookies
+}
+
+// cookieMaxAge returns how long the value of the cookie should
+// be trusted for.
+func cookieMaxAge(cookie *http.Cookie) time.Duration {
+	if cookie.MaxAge > 0 {
+		return time.Duration(cookie.MaxAge) * time.Second
+	}
+	if !cookie.Expires.IsZero() && cookie.MaxAge == 0 {
+		return time.Until(cookie.Expires)
+	}
+	return 0
+}
+
+// SignedCookie returns the value of the named cookie set with
+// SetSignedCookie and whether it was sent, untampered and not expired.
+func (r *Request) SignedCookie(name string) (string, bool) {
+	signed, ok := r.Cookie(name)
+	if !ok {
+		return "", false
+	}
+	value, err := r._app.cookieCodec().Verify(name, signed)
+	return value, err == nil
+}
+
+// SetSignedCookie signs the value of the cookie and adds it to the
+// response. The client can read the value but any change to it is
+// rejected by SignedCookie. So is the value once the max age or
+// expiry of the cookie has passed.
+//
+//  Example:
+//
+//  r.SetSignedCookie(&http.Cookie{Name: "cart", Value: "3", MaxAge: 3600})
+func (r *Request) SetSignedCookie(cookie *http.Cookie) {
+	cookie.Value = r._app.cookieCodec().Sign(cookie.Name, cookie.Value, cookieMaxAge(cookie))
+	r.Response.SetCookie(cookie)
+}
+
+// EncryptedCookie returns the value of the named cookie set with
+// SetEncryptedCookie and whether it was sent, untampered and not expired.
+func (r *Request) EncryptedCookie(name string) (string, bool) {
+	encrypted, ok := r.Cookie(name)
+	if !ok {
+		return "", false
+	}
+	value, err := r._app.cookieCodec().Decrypt(name, encrypted)
+	return value, err == nil
+}
+
+// SetEncryptedCookie encrypts the value of the cookie and adds it to
+// the response. The client can neither read nor change the value and
+// EncryptedCookie rejects it once the max age or expiry of the cookie
+// has passed.
+func (r *Request) SetEncryptedCookie(cookie *http.Cookie) error {
+	value, err := r._app.cookieCodec().Encrypt(cookie.Name, cookie.Value, cookieMaxAge(cookie))
+	if err != nil {
+		return err
+	}
+	cookie.Value = value
+	r.Response.SetCookie(cookie)
+	return nil
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// cookieFormats are the two ways CookieCodec protects values along with
+// a way to make a value that expired a minute ago.
+var cookieFormats = []struct {
+	name    string
+	encode  func(c *CookieCodec, name string, value string, maxAge time.Duration) (string, error)
+	decode  func(c *CookieCodec, name string, encoded string) (string, error)
+	expired func(c *CookieCodec, name string, value string) string
+}{
+	{
+		name: "signed",
+		encode: func(c *CookieCodec, name string, value string, maxAge time.Duration) (string, error) {
+			return c.Sign(name, value, maxAge), nil
+		},
+		decode: (*CookieCodec).Verify,
+		expired: func(c *CookieCodec, name string, value string) string {
+			payload := base64.RawURLEncoding.EncodeToString([]byte(value)) + "." +
+				strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+			return payload + "." + c.signature(c._keys[0], name, payload)
+		},
+	},
+	{
+		name:   "encrypted",
+		encode: (*CookieCodec).Encrypt,
+		decode: (*CookieCodec).Decrypt,
+		expired: func(c *CookieCodec, name string, value string) string {
+			key := c._keys[0]
+			nonce := make([]byte, key._encrypt.NonceSize())
+			plain := make([]byte, 8, 8+len(value))
+			binary.BigEndian.PutUint64(plain, uint64(time.Now().Add(-time.Minute).Unix()))
+			plain = append(plain, value...)
+			return base64.RawURLEncoding.EncodeToString(key._encrypt.Seal(nonce, nonce, plain, []byte(name)))
+		},
+	},
+}
+
+// flipByte changes a character in the middle of the encoded value.
+func flipByte(encoded string) string {
+	b := []byte(encoded)
+	i := len(b) / 2
+	if b[i] == 'A' {
+		b[i] = 'B'
+	} else {
+		b[i] = 'A'
+	}
+	return string(b)
+}
+
+func TestCookieCodec(t *testing.T) {
+	current := NewCookieCodec("current secret")
+	previous := NewCookieCodec("previous secret")
+	rotated := NewCookieCodec("current secret", "previous secret")
+	other := NewCookieCodec("other secret")
+
+	for _, format := range cookieFormats {
+		encode := func(c *CookieCodec, name string, maxAge time.Duration) string {
+			encoded, err := format.encode(c, name, "user=42", maxAge)
+			if err != nil {
+				t.Fatalf("%s: encoding failed: %v", format.name, err)
+			}
+			return encoded
+		}
+
+		tests := []struct {
+			name    string
+			encoded string
+			codec   *CookieCodec
+			cookie  string
+			value   string
+			err     error
+		}{
+			{"round trip", encode(current, "session", time.Hour), current, "session", "user=42", nil},
+			{"no expiry", encode(current, "session", 0), current, "session", "user=42", nil},
+			{"flipped byte", flipByte(encode(current, "session", time.Hour)), current, "session", "", ErrCookieInvalid},
+			{"moved to another name", encode(current, "session", time.Hour), current, "cart", "", ErrCookieInvalid},
+			{"expired", format.expired(current, "session", "user=42"), current, "session", "", ErrCookieExpired},
+			{"previous secret", encode(previous, "session", time.Hour), rotated, "session", "user=42", nil},
+			{"current secret after rotation", encode(rotated, "session", time.Hour), current, "session", "user=42", nil},
+			{"previous secret dropped", encode(previous, "session", time.Hour), current, "session", "", ErrCookieInvalid},
+			{"unknown secret", encode(other, "session", time.Hour), rotated, "session", "", ErrCookieInvalid},
+			{"garbage", "not a cookie", current, "session", "", ErrCookieInvalid},
+			{"empty", "", current, "session", "", ErrCookieInvalid},
+		}
+
+		for _, test := range tests {
+			value, err := format.decode(test.codec, test.cookie, test.encoded)
+			if err != test.err {
+				t.Errorf("%s %s: err = %v, want %v", format.name, test.name, err, test.err)
+			}
+			if value != test.value {
+				t.Errorf("%s %s: value = %q, want %q", format.name, test.name, value, test.value)
+			}
+		}
+	}
+}
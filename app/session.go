@@ -0,0 +1,376 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mcfriend99/gaga/logger"
+)
+
+// default session settings.
+const (
+	defaultSessionCookie      = "gaga_session"
+	defaultSessionPath        = "data/sessions"
+	defaultSessionIdleTimeout = 2 * 60 * 60
+	defaultSessionLifetime    = 24 * 60 * 60
+	defaultSessionGCInterval  = 10 * 60
+)
+
+// Session holds the data kept for a client between requests.
+// Changes are saved once the response is written.
+//
+//  Example:
+//
+//  func Login(r *app.Request) string {
+//  	// ...
+//  	r.Session().Regenerate()
+//  	r.Session().Set("user", user.ID)
+//  	r.Session().Flash("status", "Welcome back!")
+//  	return r.Redirect("/", 0)
+//  }
+type Session struct {
+	_id      string
+	_data    *SessionData
+	_flashes map[string]interface{}
+	_manager *sessionManager
+
+	_new       bool
+	_changed   bool
+	_renewed   bool
+	_destroyed bool
+	_replaced  bool
+}
+
+// ID returns the id of the session.
+func (s *Session) ID() string {
+	return s._id
+}
+
+// Get returns the value of the key or nil if it is not set.
+func (s *Session) Get(key string) interface{} {
+	return s._data.Values[key]
+}
+
+// Has reports whether the key is set.
+func (s *Session) Has(key string) bool {
+	_, ok := s._data.Values[key]
+	return ok
+}
+
+// Set sets the value of the key.
+// Values must be encodable as JSON when sessions are kept in files.
+func (s *Session) Set(key string, value interface{}) {
+	s._data.Values[key] = value
+	s._changed = true
+}
+
+// Delete removes the key from the session.
+func (s *Session) Delete(key string) {
+	delete(s._data.Values, key)
+	s._changed = true
+}
+
+// Flash sets a value that is only available to the next request
+// through GetFlash.
+func (s *Session) Flash(key string, value interface{}) {
+	s._data.Flashes[key] = value
+	s._changed = true
+}
+
+// GetFlash returns the value flashed by the previous request
+// or nil if there is none.
+func (s *Session) GetFlash(key string) interface{} {
+	return s._flashes[key]
+}
+
+// Regenerate moves the session to a new id, keeping its data.
+// It should be called whenever the privileges of a client change, such
+// as on login, so that an id known before can not be used after.
+func (s *Session) Regenerate() {
+	if !s._new {
+		s._manager.delete(s._id)
+	}
+	s._id = newSessionID()
+	s._renewed = true
+	s._changed = true
+}
+
+// Destroy removes the session and all its data.
+// A new session is started if the session is used again.
+func (s *Session) Destroy() {
+	if !s._new {
+		s._manager.delete(s._id)
+	}
+	s._destroyed = true
+}
+
+// Session returns the session of the request, starting a new one
+// when the client has none or its session expired.
+func (r *Request) Session() *Session {
+	if r._session == nil {
+		r._session = r._app.sessions().load(r)
+	} else if r._session._destroyed {
+		// the cookie of the destroyed session must not be used again.
+		r._session = r._app.sessions().create()
+		r._session._replaced = true
+	}
+	return r._session
+}
+
+// sessionManager loads and saves the sessions of requests.
+type sessionManager struct {
+	_store  SessionStore
+	_config SessionConfig
+	_codec  *CookieCodec
+}
+
+// sessions returns the session manager of the app, creating it
+// the first time sessions are used.
+func (g *Gaga) sessions() *sessionManager {
+	g._sessionsOnce.Do(func() {
+		// sessions that outlive the app can only be read back with the
+		// secret they were signed with.
+		store := g.Config.Session.Store
+		if g.SessionStore == nil && (store == "file" || store == "cookie") && g.Config.App.Secret == "" {
+			panic(fmt.Sprintf("the %s session store needs an app secret, set app.secret in the config", store))
+		}
+		g._sessions = _newSessionManager(g.Config.Session, g.SessionStore, g.cookieCodec())
+	})
+	return g._sessions
+}
+
+func _newSessionManager(config SessionConfig, store SessionStore, codec *CookieCodec) *sessionManager {
+	m := &sessionManager{_config: config, _codec: codec}
+	if m._config.CookieName == "" {
+		m._config.CookieName = defaultSessionCookie
+	}
+	if m._config.Path == "" {
+		m._config.Path = defaultSessionPath
+	}
+	if m._config.IdleTimeout <= 0 {
+		m._config.IdleTimeout = defaultSessionIdleTimeout
+	}
+	if m._config.Lifetime <= 0 {
+		m._config.Lifetime = defaultSessionLifetime
+	}
+	if m._config.GCInterval <= 0 {
+		m._config.GCInterval = defaultSessionGCInterval
+	}
+
+	m._store = store
+	if m._store == nil {
+		switch m._config.Store {
+		case "none":
+			m._store = nullStore{}
+		case "cookie":
+			m._store = cookieStore{}
+		case "file":
+			fileStore, err := NewFileStore(m._config.Path)
+			if err != nil {
+				panic(err)
+			}
+			m._store = fileStore
+		default:
+			m._store = NewMemoryStore()
+		}
+	}
+
+	switch m._store.(type) {
+	case nullStore, cookieStore:
+	default:
+		go m.collect(time.Duration(m._config.GCInterval) * time.Second)
+	}
+
+	return m
+}
+
+// collect removes expired sessions from the store at every interval.
+func (m *sessionManager) collect(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		if err := m._store.GC(now); err != nil {
+			logger.Error("Failed to remove expired sessions:", err)
+		}
+	}
+}
+
+// load returns the session named by the cookie of the request or
+// a new session if there is none or it expired.
+func (m *sessionManager) load(r *Request) *Session {
+	if value, ok := r.Cookie(m._config.CookieName); ok {
+		if id, data := m.read(value); data != nil {
+			if data.Expires.After(time.Now()) {
+				s := &Session{
+					_id:      id,
+					_data:    data,
+					_flashes: data.Flashes,
+					_manager: m,
+				}
+				if data.Values == nil {
+					data.Values = make(map[string]interface{})
+				}
+				data.Flashes = make(map[string]interface{})
+				s._changed = len(s._flashes) > 0
+				return s
+			}
+			m.delete(id)
+		}
+	}
+
+	return m.create()
+}
+
+// cookieSession is a session kept in the session cookie.
+type cookieSession struct {
+	ID   string       `json:"id"`
+	Data *SessionData `json:"data"`
+}
+
+// read returns the id and data of the session named by the value of
+// the session cookie. The data is nil when there is no such session.
+func (m *sessionManager) read(value string) (string, *SessionData) {
+	if _, ok := m._store.(cookieStore); ok {
+		plain, err := m._codec.Decrypt(m._config.CookieName, value)
+		if err != nil {
+			return "", nil
+		}
+
+		stored := cookieSession{}
+		if err := json.Unmarshal([]byte(plain), &stored); err != nil {
+			return "", nil
+		}
+		return stored.ID, stored.Data
+	}
+
+	id, err := m._codec.Verify(m._config.CookieName, value)
+	if err != nil {
+		return "", nil
+	}
+
+	data, err := m._store.Load(id)
+	if err != nil {
+		logger.Error("Failed to load session:", err)
+		return "", nil
+	}
+	return id, data
+}
+
+// create starts a new session.
+func (m *sessionManager) create() *Session {
+	return &Session{
+		_id: newSessionID(),
+		_data: &SessionData{
+			Values:  make(map[string]interface{}),
+			Flashes: make(map[string]interface{}),
+			Created: time.Now(),
+		},
+		_manager: m,
+		_new:     true,
+	}
+}
+
+// save stores the session of the request if it was used and sets the
+// session cookie on the response when the client needs a new one.
+func (m *sessionManager) save(r *Request) {
+	// sessions that are not kept have no use for a cookie.
+	if _, ok := m._store.(nullStore); ok {
+		return
+	}
+
+	s := r._session
+	if s._destroyed || s._replaced && !s._changed {
+		r.Response.SetCookie(m.cookie("", -1))
+		return
+	}
+
+	// untouched new sessions are not worth keeping.
+	if s._new && !s._changed {
+		return
+	}
+
+	// sessions expire when idle for too long or past their lifetime,
+	// whichever comes first.
+	now := time.Now()
+	end := s._data.Created.Add(time.Duration(m._config.Lifetime) * time.Second)
+	s._data.LastSeen = now
+	s._data.Expires = now.Add(time.Duration(m._config.IdleTimeout) * time.Second)
+	if end.Before(s._data.Expires) {
+		s._data.Expires = end
+	}
+
+	// the cookie store keeps the whole session in the cookie so the
+	// cookie changes whenever the session does.
+	if _, ok := m._store.(cookieStore); ok {
+		b, err := json.Marshal(cookieSession{ID: s._id, Data: s._data})
+		if err == nil {
+			var value string
+			if value, err = m._codec.Encrypt(m._config.CookieName, string(b), end.Sub(now)); err == nil {
+				if len(value) > 4000 {
+					logger.Warn("Session cookie is larger than most browsers accept.")
+				}
+				r.Response.SetCookie(m.cookie(value, int(end.Sub(now).Seconds())))
+			}
+		}
+		if err != nil {
+			logger.Error("Failed to save session:", err)
+		}
+		return
+	}
+
+	if err := m._store.Save(s._id, s._data); err != nil {
+		logger.Error("Failed to save session:", err)
+		return
+	}
+
+	if s._new || s._renewed {
+		value := m._codec.Sign(m._config.CookieName, s._id, 0)
+		r.Response.SetCookie(m.cookie(value, int(end.Sub(now).Seconds())))
+	}
+}
+
+func (m *sessionManager) delete(id string) {
+	if err := m._store.Delete(id); err != nil {
+		logger.Error("Failed to delete session:", err)
+	}
+}
+
+// cookie returns the session cookie holding the value.
+func (m *sessionManager) cookie(value string, maxAge int) *http.Cookie {
+	sameSite := http.SameSiteLaxMode
+	switch strings.ToLower(m._config.SameSite) {
+	case "strict":
+		sameSite = http.SameSiteStrictMode
+	case "none":
+		sameSite = http.SameSiteNoneMode
+	}
+
+	if maxAge == 0 {
+		maxAge = -1
+	}
+
+	return &http.Cookie{
+		Name:     m._config.CookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   m._config.Secure,
+		HttpOnly: true,
+		SameSite: sameSite,
+	}
+}
+
+// newSessionID returns a random session id.
+func newSessionID() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,216 @@
+package app
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SessionData is what a session store keeps for a session.
+type SessionData struct {
+	Values   map[string]interface{} `json:"values"`
+	Flashes  map[string]interface{} `json:"flashes"`
+	Created  time.Time              `json:"created"`
+	LastSeen time.Time              `json:"last_seen"`
+	Expires  time.Time              `json:"expires"`
+}
+
+// copy returns a copy of the data that shares none of its maps.
+func (d *SessionData) copy() *SessionData {
+	c := *d
+	c.Values = make(map[string]interface{}, len(d.Values))
+	for key, value := range d.Values {
+		c.Values[key] = value
+	}
+	c.Flashes = make(map[string]interface{}, len(d.Flashes))
+	for key, value := range d.Flashes {
+		c.Flashes[key] = value
+	}
+	return &c
+}
+
+// SessionStore keeps the data of sessions between requests.
+type SessionStore interface {
+	// Load returns the data of the session with the id or nil if
+	// there is none.
+	Load(id string) (*SessionData, error)
+
+	// Save stores the data of the session with the id.
+	Save(id string, data *SessionData) error
+
+	// Delete removes the session with the id.
+	Delete(id string) error
+
+	// GC removes every session that expired before now.
+	GC(now time.Time) error
+}
+
+// MemoryStore keeps sessions in memory. Sessions are lost when the
+// application stops.
+type MemoryStore struct {
+	_sessions map[string]*SessionData
+	_lock     sync.RWMutex
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{_sessions: make(map[string]*SessionData)}
+}
+
+func (s *MemoryStore) Load(id string) (*SessionData, error) {
+	s._lock.RLock()
+	defer s._lock.RUnlock()
+
+	if data, ok := s._sessions[id]; ok {
+		return data.copy(), nil
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) Save(id string, data *SessionData) error {
+	s._lock.Lock()
+	s._sessions[id] = data.copy()
+	s._lock.Unlock()
+	return nil
+}
+
+func (s *MemoryStore) Delete(id string) error {
+	s._lock.Lock()
+	delete(s._sessions, id)
+	s._lock.Unlock()
+	return nil
+}
+
+func (s *MemoryStore) GC(now time.Time) error {
+	s._lock.Lock()
+	defer s._lock.Unlock()
+
+	for id, data := range s._sessions {
+		if data.Expires.Before(now) {
+			delete(s._sessions, id)
+		}
+	}
+	return nil
+}
+
+// FileStore keeps every session in a JSON file in a directory.
+// Values are stored as JSON, so numbers come back as float64 and
+// structs as maps.
+type FileStore struct {
+	_dir  string
+	_lock sync.Mutex
+}
+
+// NewFileStore creates a FileStore keeping sessions in dir.
+// The directory is created if it does not exist.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileStore{_dir: dir}, nil
+}
+
+// file returns the path of the file of the session.
+// Ids are checked so they can never point outside of the directory.
+func (s *FileStore) file(id string) (string, bool) {
+	if id == "" || strings.Trim(id, "0123456789abcdef") != "" {
+		return "", false
+	}
+	return filepath.Join(s._dir, id+".json"), true
+}
+
+func (s *FileStore) Load(id string) (*SessionData, error) {
+	file, ok := s.file(id)
+	if !ok {
+		return nil, nil
+	}
+
+	s._lock.Lock()
+	b, err := ioutil.ReadFile(file)
+	s._lock.Unlock()
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	data := &SessionData{}
+	if err := json.Unmarshal(b, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *FileStore) Save(id string, data *SessionData) error {
+	file, ok := s.file(id)
+	if !ok {
+		return nil
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	s._lock.Lock()
+	defer s._lock.Unlock()
+
+	// write to a temporary file first so readers never see half a session.
+	tmp := file + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+func (s *FileStore) Delete(id string) error {
+	file, ok := s.file(id)
+	if !ok {
+		return nil
+	}
+
+	s._lock.Lock()
+	defer s._lock.Unlock()
+
+	if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) GC(now time.Time) error {
+	files, err := filepath.Glob(filepath.Join(s._dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		id := strings.TrimSuffix(filepath.Base(file), ".json")
+		data, err := s.Load(id)
+		if err != nil || data == nil || data.Expires.Before(now) {
+			if err := s.Delete(id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// nullStore keeps no sessions at all. Sessions only last for
+// the request they were created in.
+type nullStore struct{}
+
+func (nullStore) Load(id string) (*SessionData, error)    { return nil, nil }
+func (nullStore) Save(id string, data *SessionData) error { return nil }
+func (nullStore) Delete(id string) error                  { return nil }
+func (nullStore) GC(now time.Time) error                  { return nil }
+
+// cookieStore keeps no sessions on the server. Sessions are kept
+// encrypted in the session cookie instead.
+type cookieStore struct {
+	nullStore
+}
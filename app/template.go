@@ -1,5 +1,380 @@
 package app
 
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mcfriend99/gaga/logger"
+)
+
+// default view settings.
+const (
+	defaultViewPath      = "views"
+	defaultViewExtension = ".html"
+	partialsDir          = "partials"
+	layoutsDir           = "layouts"
+)
+
+// extendsPattern finds the layout a view extends.
+var extendsPattern = regexp.MustCompile(`{{-?\s*extends\s+"([^"]+)"\s*-?}}`)
+
+// errorLinePattern finds the template and line an error of
+// the template packages happened at.
+var errorLinePattern = regexp.MustCompile(`template:\s?([^:\s]+):(\d+)`)
+
+// Template is a view compiled along with the layouts it extends and
+// every partial, ready to be executed.
+//
+// A view names its layout with extends and fills the blocks of the
+// layout with define. Partials are the views under views/partials and
+// are included with the template action.
+//
+//  Example:
+//
+//  <!-- views/layouts/main.html -->
+//  <html>
+//  <head><title>{{ block "title" . }}Gaga{{ end }}</title></head>
+//  <body>{{ template "partials/nav" . }}{{ block "content" . }}{{ end }}</body>
+//  </html>
+//
+//  <!-- views/users/show.html -->
+//  {{ extends "layouts/main" }}
+//  {{ define "title" }}{{ .Data.Name }}{{ end }}
+//  {{ define "content" }}<h1>{{ .Data.Name }}</h1>{{ end }}
 type Template struct {
 	Path string
+	Name string
+
+	_template *template.Template
+	_root     string
+	_files    map[string]string
+	_modTimes map[string]time.Time
+	_partials string
+}
+
+// Execute renders the view with data. The compiled view is shared by
+// every render so it is escaped only once.
+func (t *Template) Execute(data interface{}) (string, error) {
+	var buffer bytes.Buffer
+	if err := t._template.ExecuteTemplate(&buffer, t._root, data); err != nil {
+		return "", t.error(err)
+	}
+	return buffer.String(), nil
+}
+
+// ViewData is what views rendered for a request get: the data they were
+// rendered with and the request, which view funcs such as csrf_field
+// take.
+//
+//  Example:
+//
+//  <h1>{{ .Data.Name }}</h1>
+//  <form method="post">{{ csrf_field .Request }}</form>
+type ViewData struct {
+	Data    interface{}
+	Request *Request
+}
+
+// error returns err as a ViewError pointing at the file it happened in.
+func (t *Template) error(err error) error {
+	return viewError(err, t.Name, t._files)
+}
+
+// ViewError is an error in a view along with the file and line
+// it happened at when known.
+type ViewError struct {
+	View string
+	File string
+	Line int
+	Err  error
+}
+
+func (e *ViewError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.File, e.Err)
+}
+
+// viewError wraps an error of the template packages in a ViewError.
+// files maps the names of the templates to their files.
+func viewError(err error, view string, files map[string]string) *ViewError {
+	e := &ViewError{View: view, File: files[view], Err: err}
+	if m := errorLinePattern.FindStringSubmatch(err.Error()); m != nil {
+		if file, ok := files[m[1]]; ok {
+			e.File = file
+			e.Line, _ = strconv.Atoi(m[2])
+		}
+	}
+	return e
+}
+
+// viewEngine compiles views from the views directory and caches them.
+// In dev mode cached views are compiled again when any of their files
+// change.
+type viewEngine struct {
+	_dir   string
+	_ext   string
+	_dev   bool
+	_funcs template.FuncMap
+	_cache map[string]*Template
+	_lock  sync.RWMutex
+}
+
+// views returns the view engine of the app, creating it the first
+// time views are used.
+func (g *Gaga) views() *viewEngine {
+	g._viewsOnce.Do(func() {
+		g._views = _newViewEngine(g.Config.Views, g.Config.Dev, g.viewFuncs())
+	})
+	return g._views
+}
+
+func _newViewEngine(config ViewConfig, dev bool, funcs template.FuncMap) *viewEngine {
+	e := &viewEngine{
+		_dir:   config.Path,
+		_ext:   config.Extension,
+		_dev:   dev,
+		_cache: make(map[string]*Template),
+	}
+	if e._dir == "" {
+		e._dir = defaultViewPath
+	}
+	if e._ext == "" {
+		e._ext = defaultViewExtension
+	}
+	if !strings.HasPrefix(e._ext, ".") {
+		e._ext = "." + e._ext
+	}
+
+	e._funcs = template.FuncMap{
+		// extends is read before parsing, it renders nothing.
+		"extends": func(layout string) string { return "" },
+	}
+	for name, fn := range funcs {
+		e._funcs[name] = fn
+	}
+	return e
+}
+
+// file returns the file of the named view.
+func (e *viewEngine) file(name string) string {
+	return filepath.Join(e._dir, filepath.FromSlash(name)+e._ext)
+}
+
+// lookup returns the named view, compiling it the first time
+// and, in dev mode, whenever its files changed.
+func (e *viewEngine) lookup(name string) (*Template, error) {
+	e._lock.RLock()
+	t, ok := e._cache[name]
+	e._lock.RUnlock()
+	if ok && (!e._dev || !e.stale(t)) {
+		return t, nil
+	}
+
+	t, err := e.compile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	e._lock.Lock()
+	e._cache[name] = t
+	e._lock.Unlock()
+	return t, nil
+}
+
+// compile parses the named view with the layouts it extends and every
+// partial. Layouts are parsed from the outermost in so the blocks of a
+// view replace the ones of its layouts.
+func (e *viewEngine) compile(name string) (*Template, error) {
+	t := &Template{
+		Path:      e.file(name),
+		Name:      name,
+		_files:    make(map[string]string),
+		_modTimes: make(map[string]time.Time),
+	}
+
+	var chain []string
+	sources := make(map[string]string)
+	for current := name; current != ""; {
+		if _, ok := sources[current]; ok {
+			return nil, &ViewError{View: name, File: t.Path, Err: fmt.Errorf("layout %q extends itself", current)}
+		}
+
+		file := e.file(current)
+		b, err := t.read(file)
+		if err != nil {
+			return nil, &ViewError{View: name, File: file, Err: err}
+		}
+		chain = append(chain, current)
+		sources[current] = string(b)
+		t._files[current] = file
+
+		current = ""
+		if m := extendsPattern.FindSubmatch(b); m != nil {
+			current = string(m[1])
+		}
+	}
+
+	partials, err := e.partials()
+	if err != nil {
+		return nil, &ViewError{View: name, File: filepath.Join(e._dir, partialsDir), Err: err}
+	}
+
+	set := template.New("").Funcs(e._funcs)
+	for _, partial := range partials {
+		if _, ok := sources[partial]; ok {
+			continue
+		}
+
+		file := e.file(partial)
+		b, err := t.read(file)
+		if err != nil {
+			return nil, &ViewError{View: name, File: file, Err: err}
+		}
+		t._files[partial] = file
+		if _, err := set.New(partial).Parse(string(b)); err != nil {
+			return nil, t.error(err)
+		}
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		if _, err := set.New(chain[i]).Parse(sources[chain[i]]); err != nil {
+			return nil, t.error(err)
+		}
+	}
+
+	t._template = set
+	t._root = chain[len(chain)-1]
+	t._partials = strings.Join(partials, ",")
+	return t, nil
+}
+
+// read returns the content of the file of the view, noting when it
+// was last changed.
+func (t *Template) read(file string) ([]byte, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	t._modTimes[file] = info.ModTime()
+	return ioutil.ReadFile(file)
+}
+
+// stale reports whether any file of the view changed since it was
+// compiled or partials were added or removed.
+func (e *viewEngine) stale(t *Template) bool {
+	for file, modTime := range t._modTimes {
+		info, err := os.Stat(file)
+		if err != nil || !info.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+
+	partials, err := e.partials()
+	return err != nil || strings.Join(partials, ",") != t._partials
+}
+
+// partials returns the names of every partial.
+func (e *viewEngine) partials() ([]string, error) {
+	return e.names(filepath.Join(e._dir, partialsDir), nil)
+}
+
+// names returns the names of the views in dir, leaving out the
+// directories to skip.
+func (e *viewEngine) names(dir string, skip []string) ([]string, error) {
+	var names []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(e._dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if info.IsDir() {
+			for _, s := range skip {
+				if rel == s {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+
+		if strings.HasSuffix(rel, e._ext) {
+			names = append(names, strings.TrimSuffix(rel, e._ext))
+		}
+		return nil
+	})
+	sort.Strings(names)
+	return names, err
+}
+
+// precompile compiles every view up front so the first requests don't
+// have to and broken views show up on startup. Layouts and partials are
+// compiled along with the views using them.
+func (e *viewEngine) precompile() {
+	names, err := e.names(e._dir, []string{partialsDir, layoutsDir})
+	if err != nil {
+		logger.Error("Failed to list views:", err)
+		return
+	}
+
+	for _, name := range names {
+		if _, err := e.lookup(name); err != nil {
+			logger.Error("Failed to compile view:", err)
+		}
+	}
+}
+
+// render renders the named view for the request with data.
+func (e *viewEngine) render(name string, r *Request, data interface{}) (string, error) {
+	t, err := e.lookup(name)
+	if err != nil {
+		return "", err
+	}
+	return t.Execute(ViewData{Data: data, Request: r})
+}
+
+// View responds with the named view under the views directory rendered
+// with data. Names are paths relative to the views directory without
+// the extension. The view gets data and the request as a ViewData.
+//
+//  Example:
+//
+//  func User(r *app.Request) string {
+//  	// ...
+//  	return r.View("users/show", user)
+//  }
+func (r *Request) View(name string, data interface{}) string {
+	html, err := r._app.views().render(name, r, data)
+	if err != nil {
+		logger.Error("Failed to render view:", err)
+		if viewErr, ok := err.(*ViewError); ok && r._app.Config.Dev {
+			r.Response.ContentType = "text/html; charset=utf-8"
+			r.Response.StatusCode = http.StatusInternalServerError
+			return viewErr.overlay()
+		}
+		return r._app.errorResponse(r, http.StatusInternalServerError)
+	}
+
+	if r.Response.ContentType == "" {
+		r.Response.ContentType = "text/html; charset=utf-8"
+	}
+	return html
 }
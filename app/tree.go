@@ -0,0 +1,397 @@
+package app
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// segment kinds of a route pattern.
+const (
+	segmentStatic = iota
+	segmentParam
+	segmentCatchAll
+)
+
+// segment is a single compiled part of a route pattern.
+type segment struct {
+	kind       int
+	value      string
+	optional   bool
+	constraint string
+}
+
+// leaf is a route attached to a node in the route tree along with
+// the names of the params captured on the way down to it.
+type leaf struct {
+	route   *Route
+	names   []string
+	omitted []string
+}
+
+// node is a node in the route prefix tree. Each node represents a
+// path segment and routes are attached to the node of their last segment.
+type node struct {
+	_static   map[string]*node
+	_param    *node
+	_catchAll *node
+	_leaves   []leaf
+}
+
+func _newNode() *node {
+	return &node{_static: make(map[string]*node)}
+}
+
+// insert adds the route to the tree under the given segments.
+func (n *node) insert(segments []segment, l leaf) {
+	current := n
+	for _, s := range segments {
+		switch s.kind {
+		case segmentStatic:
+			child, ok := current._static[s.value]
+			if !ok {
+				child = _newNode()
+				current._static[s.value] = child
+			}
+			current = child
+		case segmentParam:
+			if current._param == nil {
+				current._param = _newNode()
+			}
+			current = current._param
+		case segmentCatchAll:
+			if current._catchAll == nil {
+				current._catchAll = _newNode()
+			}
+			current = current._catchAll
+		}
+	}
+	current._leaves = append(current._leaves, l)
+}
+
+// match walks the tree looking for a route matching the path segments.
+// Static segments are preferred over params and params over catch-alls.
+func (n *node) match(parts []string, values []string, params map[string]string) *Route {
+	if len(parts) == 0 {
+		if route := n.matchLeaves(values, params); route != nil {
+			return route
+		}
+	} else {
+		if child, ok := n._static[parts[0]]; ok {
+			if route := child.match(parts[1:], values, params); route != nil {
+				return route
+			}
+		}
+
+		if n._param != nil && parts[0] != "" {
+			if route := n._param.match(parts[1:], append(values, parts[0]), params); route != nil {
+				return route
+			}
+		}
+	}
+
+	if n._catchAll != nil {
+		return n._catchAll.matchLeaves(append(values, strings.Join(parts, "/")), params)
+	}
+
+	return nil
+}
+
+// matchLeaves returns the first route attached to the node whose
+// validators accept the captured values.
+func (n *node) matchLeaves(values []string, params map[string]string) *Route {
+	for _, l := range n._leaves {
+		if !l.route.accepts(l.names, values) {
+			continue
+		}
+
+		for i, name := range l.names {
+			if name == "" {
+				continue
+			}
+			if v, ok := l.route._paramDefaults[name]; ok && values[i] == "" {
+				params[name] = v
+			} else {
+				params[name] = values[i]
+			}
+		}
+		for _, name := range l.omitted {
+			params[name] = l.route._paramDefaults[name]
+		}
+		return l.route
+	}
+	return nil
+}
+
+// splitPath breaks a route path into its segments.
+// A single trailing slash is ignored.
+func splitPath(path string) []string {
+	path = strings.TrimPrefix(path, "/")
+	path = strings.TrimSuffix(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// parsePattern compiles a route path such as /users/{id:int}/{tab?}
+// or /docs/{path*} into its segments.
+func parsePattern(path string) []segment {
+	var segments []segment
+	for _, part := range splitPath(path) {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			name := part[1 : len(part)-1]
+			optional := strings.HasSuffix(name, "?")
+			name = strings.TrimSuffix(name, "?")
+
+			constraint := ""
+			if i := strings.Index(name, ":"); i >= 0 {
+				name, constraint = name[:i], name[i+1:]
+			}
+
+			kind := segmentParam
+			if strings.HasSuffix(name, "*") {
+				kind = segmentCatchAll
+				name = strings.TrimSuffix(name, "*")
+			}
+
+			segments = append(segments, segment{
+				kind:       kind,
+				value:      name,
+				optional:   optional,
+				constraint: constraint,
+			})
+		} else {
+			segments = append(segments, segment{kind: segmentStatic, value: part})
+		}
+	}
+	return segments
+}
+
+// expandPattern returns every concrete variant of the segments with
+// optional params either present or left out.
+func expandPattern(segments []segment) [][]segment {
+	variants := [][]segment{nil}
+	for _, s := range segments {
+		var next [][]segment
+		for _, v := range variants {
+			next = append(next, append(append([]segment{}, v...), s))
+			if s.optional {
+				next = append(next, v)
+			}
+		}
+		variants = next
+	}
+	return variants
+}
+
+// validators that are common enough to be worth checking without regex.
+var simpleValidators = map[string]func(byte) bool{
+	`\d+`:            func(c byte) bool { return c >= '0' && c <= '9' },
+	`[0-9]+`:         func(c byte) bool { return c >= '0' && c <= '9' },
+	`[a-z]+`:         func(c byte) bool { return c >= 'a' && c <= 'z' },
+	`[A-Z]+`:         func(c byte) bool { return c >= 'A' && c <= 'Z' },
+	`[a-zA-Z]+`:      isAlpha,
+	`[a-zA-Z0-9]+`:   func(c byte) bool { return isAlpha(c) || c >= '0' && c <= '9' },
+	`\w+`:            func(c byte) bool { return isAlpha(c) || c >= '0' && c <= '9' || c == '_' },
+	`[a-zA-Z0-9_-]+`: func(c byte) bool { return isAlpha(c) || c >= '0' && c <= '9' || c == '_' || c == '-' },
+}
+
+func isAlpha(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// compileValidator turns a Where pattern into a matcher func.
+// The pattern must match the whole param value.
+func compileValidator(pattern string) func(string) bool {
+	if test, ok := simpleValidators[pattern]; ok {
+		return func(s string) bool {
+			if s == "" {
+				return false
+			}
+			for i := 0; i < len(s); i++ {
+				if !test(s[i]) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+
+	exp := regexp.MustCompile("^(?:" + pattern + ")$")
+	return exp.MatchString
+}
+
+// routeTable holds the per-method route trees of the routes
+// bound to a host pattern, or to any host when it has none.
+type routeTable struct {
+	_host  *hostPattern
+	_trees map[string]*node
+}
+
+func _newRouteTable(host *hostPattern) *routeTable {
+	return &routeTable{_host: host, _trees: make(map[string]*node)}
+}
+
+// insert adds the route to the tree of the method.
+func (t *routeTable) insert(method string, route *Route) {
+	tree, ok := t._trees[method]
+	if !ok {
+		tree = _newNode()
+		t._trees[method] = tree
+	}
+
+	segments := parsePattern(route.Path)
+	if route._isStatic {
+		segments = append(segments, segment{kind: segmentCatchAll})
+	}
+	for i, s := range segments {
+		if s.kind == segmentCatchAll && i != len(segments)-1 {
+			panic("catch-all param must be the last part of route " + route.Path)
+		}
+	}
+
+	for _, variant := range expandPattern(segments) {
+		l := leaf{route: route}
+		present := make(map[string]bool)
+		for _, s := range variant {
+			if s.kind == segmentParam || s.kind == segmentCatchAll {
+				l.names = append(l.names, s.value)
+				present[s.value] = true
+			}
+		}
+		for _, s := range segments {
+			if s.kind != segmentStatic && !present[s.value] {
+				l.omitted = append(l.omitted, s.value)
+			}
+		}
+		tree.insert(variant, l)
+	}
+}
+
+// find returns the route matching the method and path segments.
+func (t *routeTable) find(method string, parts []string, params map[string]string) *Route {
+	for _, m := range []string{method, ""} {
+		if tree, ok := t._trees[m]; ok {
+			if route := tree.match(parts, nil, params); route != nil {
+				return route
+			}
+		}
+	}
+	return nil
+}
+
+// router holds the route tables built from a Routing
+// as well as the named and static routes.
+type router struct {
+	_hosts    []*routeTable
+	_fallback *routeTable
+	_named    map[string]*Route
+	_static   []*Route
+}
+
+// _newRouter compiles all routes in routing into route tables.
+// Routes bound to a domain go to the table of their domain in the
+// order the domains were declared, all others go to the fallback table.
+func _newRouter(routing *Routing) *router {
+	rt := &router{
+		_fallback: _newRouteTable(nil),
+		_named:    make(map[string]*Route),
+	}
+
+	tables := make(map[*Routing]*routeTable)
+	for _, group := range routing._domains {
+		table := _newRouteTable(_newHostPattern(group))
+		tables[group] = table
+		rt._hosts = append(rt._hosts, table)
+	}
+
+	for method, routes := range routing.Routes {
+		for _, route := range routes {
+			route.compile()
+			if route._name != "" {
+				rt._named[route._name] = route
+			}
+			if route._isStatic {
+				rt._static = append(rt._static, route)
+			}
+
+			if table, ok := tables[route._domainGroup]; ok {
+				table.insert(method, route)
+			} else {
+				rt._fallback.insert(method, route)
+			}
+		}
+	}
+
+	return rt
+}
+
+// tables returns the route tables that apply to the host
+// along with the params captured from it.
+func (rt *router) tables(host string) ([]*routeTable, []map[string]string) {
+	var tables []*routeTable
+	var params []map[string]string
+
+	labels := splitHost(host)
+	for _, table := range rt._hosts {
+		if captured, ok := table._host.match(labels); ok {
+			tables = append(tables, table)
+			params = append(params, captured)
+		}
+	}
+	return append(tables, rt._fallback), append(params, nil)
+}
+
+// find returns the route matching the method, host and path segments
+// along with the params captured from the host and path.
+func (rt *router) find(method string, host string, parts []string) (*Route, map[string]string) {
+	tables, hostParams := rt.tables(host)
+	for i, table := range tables {
+		params := make(map[string]string)
+		for name, value := range hostParams[i] {
+			params[name] = value
+		}
+
+		if route := table.find(method, parts, params); route != nil {
+			return route, params
+		}
+	}
+	return nil, nil
+}
+
+// allowed returns the methods with a route matching the host and
+// path segments.
+// HEAD is allowed wherever GET is and OPTIONS is always allowed
+// once the path matches any route.
+func (rt *router) allowed(host string, parts []string) []string {
+	found := make(map[string]bool)
+
+	tables, _ := rt.tables(host)
+	for _, table := range tables {
+		for method, tree := range table._trees {
+			if method == "" {
+				continue
+			}
+			if tree.match(parts, nil, make(map[string]string)) != nil {
+				found[method] = true
+			}
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+
+	if found[http.MethodGet] {
+		found[http.MethodHead] = true
+	}
+	found[http.MethodOptions] = true
+
+	methods := make([]string, 0, len(found))
+	for method := range found {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
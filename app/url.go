@@ -0,0 +1,128 @@
+package app
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	_urlRouter     *router
+	_urlRouterLock sync.RWMutex
+)
+
+// URL generates the path to the route with the given name.
+// Params fill the placeholders in the route's path and are checked
+// against its Where validators. Empty optional and catch-all params are
+// left out and params not in the path are added as a query string.
+// Routes bound to a domain get a scheme relative URL with the host.
+//
+//  Example:
+//
+//  app.URL("user.edit", map[string]interface{}{"id": 42, "tab": "profile"})
+//  // => /users/42/edit?tab=profile
+func URL(name string, params map[string]interface{}) (string, error) {
+	_urlRouterLock.RLock()
+	rt := _urlRouter
+	_urlRouterLock.RUnlock()
+
+	if rt == nil {
+		return "", fmt.Errorf("no routes have been built yet")
+	}
+	return rt.url(name, params)
+}
+
+// URL generates the path to the named route of the app.
+// See the URL function for details.
+func (g *Gaga) URL(name string, params map[string]interface{}) (string, error) {
+	g._routesOnce.Do(g.buildRoutes)
+	return g._router.url(name, params)
+}
+
+// useForURL makes rt the router used by the URL function.
+func (rt *router) useForURL() {
+	_urlRouterLock.Lock()
+	_urlRouter = rt
+	_urlRouterLock.Unlock()
+}
+
+func (rt *router) url(name string, params map[string]interface{}) (string, error) {
+	route, ok := rt._named[name]
+	if !ok {
+		return "", fmt.Errorf("no route named %q", name)
+	}
+
+	used := make(map[string]bool)
+	var parts []string
+
+	for _, s := range parsePattern(route.Path) {
+		if s.kind == segmentStatic {
+			parts = append(parts, s.value)
+			continue
+		}
+
+		value := ""
+		if v, ok := params[s.value]; ok && v != nil {
+			value = fmt.Sprint(v)
+		}
+		used[s.value] = true
+
+		if value == "" {
+			if s.optional || s.kind == segmentCatchAll {
+				continue
+			}
+			return "", fmt.Errorf("missing param %q for route %q", s.value, name)
+		}
+
+		if test, ok := route._validators[s.value]; ok && !test(value) {
+			return "", fmt.Errorf("param %q of route %q does not accept %q", s.value, name, value)
+		}
+		if s.kind == segmentCatchAll {
+			for _, part := range strings.Split(strings.Trim(value, "/"), "/") {
+				parts = append(parts, url.PathEscape(part))
+			}
+		} else {
+			parts = append(parts, url.PathEscape(value))
+		}
+	}
+
+	path := "/" + strings.Join(parts, "/")
+
+	// routes bound to a domain get a scheme relative URL.
+	if route._domainGroup != nil {
+		var labels []string
+		for _, s := range parseHost(route._domainGroup._domain) {
+			if s.kind == segmentStatic {
+				labels = append(labels, s.value)
+				continue
+			}
+
+			v, ok := params[s.value]
+			if !ok || v == nil || fmt.Sprint(v) == "" {
+				return "", fmt.Errorf("missing param %q for route %q", s.value, name)
+			}
+			used[s.value] = true
+			labels = append(labels, strings.ToLower(fmt.Sprint(v)))
+		}
+		path = "//" + strings.Join(labels, ".") + path
+	}
+
+	var names []string
+	for key := range params {
+		if !used[key] {
+			names = append(names, key)
+		}
+	}
+	if len(names) > 0 {
+		sort.Strings(names)
+		query := url.Values{}
+		for _, key := range names {
+			query.Add(key, fmt.Sprint(params[key]))
+		}
+		path += "?" + query.Encode()
+	}
+
+	return path, nil
+}
@@ -0,0 +1,223 @@
+package app
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ValidationErrors holds the messages of the fields that failed
+// binding or validation, keyed by field name.
+type ValidationErrors map[string][]string
+
+// Add records a message for the field.
+func (e ValidationErrors) Add(field string, message string) {
+	e[field] = append(e[field], message)
+}
+
+func (e ValidationErrors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	messages := make([]string, 0, len(fields))
+	for _, field := range fields {
+		messages = append(messages, field+" "+strings.Join(e[field], ", "))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// validationRules check a non-empty field value against the argument
+// of a rule and return a message when it fails.
+var validationRules = map[string]func(v reflect.Value, arg string) string{
+	"min": func(v reflect.Value, arg string) string {
+		return compareSize(v, arg, "at least", func(size, limit float64) bool { return size >= limit })
+	},
+	"max": func(v reflect.Value, arg string) string {
+		return compareSize(v, arg, "at most", func(size, limit float64) bool { return size <= limit })
+	},
+	"len": func(v reflect.Value, arg string) string {
+		return compareSize(v, arg, "exactly", func(size, limit float64) bool { return size == limit })
+	},
+	"email": func(v reflect.Value, arg string) string {
+		s := fmt.Sprint(v.Interface())
+		if address, err := mail.ParseAddress(s); err != nil || address.Address != s {
+			return "must be a valid email address"
+		}
+		return ""
+	},
+	"url": func(v reflect.Value, arg string) string {
+		if u, err := url.ParseRequestURI(fmt.Sprint(v.Interface())); err != nil || u.Scheme == "" || u.Host == "" {
+			return "must be a valid URL"
+		}
+		return ""
+	},
+	"oneof": func(v reflect.Value, arg string) string {
+		s := fmt.Sprint(v.Interface())
+		for _, option := range strings.Fields(arg) {
+			if s == option {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(strings.Fields(arg), ", ")
+	},
+	"numeric": matchRule(builtinConstraints["float"], "must be a number"),
+	"alpha":   matchRule(builtinConstraints["alpha"], "must contain only letters"),
+	"alnum":   matchRule(builtinConstraints["alnum"], "must contain only letters and digits"),
+	"slug":    matchRule(isSlug, "must be a valid slug"),
+	"uuid":    matchRule(isUUID, "must be a valid UUID"),
+}
+
+// matchRule turns a string test into a validation rule.
+func matchRule(test func(string) bool, message string) func(reflect.Value, string) string {
+	return func(v reflect.Value, arg string) string {
+		if !test(fmt.Sprint(v.Interface())) {
+			return message
+		}
+		return ""
+	}
+}
+
+// compareSize compares the length of strings and collections or the
+// value of numbers to the limit in arg.
+func compareSize(v reflect.Value, arg string, relation string, ok func(size, limit float64) bool) string {
+	limit, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		panic(fmt.Sprintf("invalid validation argument %q", arg))
+	}
+
+	var size float64
+	unit := ""
+	switch v.Kind() {
+	case reflect.String:
+		size, unit = float64(utf8.RuneCountInString(v.String())), " characters"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		size, unit = float64(v.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		size = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		size = v.Float()
+	default:
+		return ""
+	}
+
+	if !ok(size, limit) {
+		if unit != "" {
+			return fmt.Sprintf("must have %s %s%s", relation, arg, unit)
+		}
+		return fmt.Sprintf("must be %s %s", relation, arg)
+	}
+	return ""
+}
+
+// Validate checks the fields of the struct v points to against the
+// rules in their validate tags and returns ValidationErrors keyed by
+// field name when any fails.
+//
+//  Rules include:
+//   required, min=n, max=n, len=n, email, url, oneof=a b c,
+//   numeric, alpha, alnum, slug, uuid.
+//
+//  Example:
+//
+//  type SignUp struct {
+//  	Email string `form:"email" validate:"required,email,max=64"`
+//  	Name  string `form:"name" validate:"required,min=3"`
+//  }
+func Validate(v interface{}) error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot validate %T, it is not a struct", v)
+	}
+
+	errs := ValidationErrors{}
+	validateStruct(value, "", errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateStruct(v reflect.Value, prefix string, errs ValidationErrors) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		value := v.Field(i)
+		name := prefix + fieldName(field)
+		if field.Anonymous {
+			name = strings.TrimSuffix(prefix, ".")
+		}
+
+		if rules := field.Tag.Get("validate"); rules != "" && rules != "-" {
+			validateField(value, name, rules, errs)
+		}
+
+		inner := reflect.Indirect(value)
+		if inner.Kind() == reflect.Struct && inner.Type() != timeType {
+			if field.Anonymous {
+				validateStruct(inner, prefix, errs)
+			} else {
+				validateStruct(inner, name+".", errs)
+			}
+		}
+	}
+}
+
+func validateField(v reflect.Value, name string, rules string, errs ValidationErrors) {
+	for _, rule := range strings.Split(rules, ",") {
+		rule = strings.TrimSpace(rule)
+		arg := ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			rule, arg = rule[:i], rule[i+1:]
+		}
+
+		if rule == "required" {
+			if v.IsZero() {
+				errs.Add(name, "is required")
+				return
+			}
+			continue
+		}
+
+		test, ok := validationRules[rule]
+		if !ok {
+			panic(fmt.Sprintf("unknown validation rule %q", rule))
+		}
+
+		// other rules only apply to fields that were given.
+		value := reflect.Indirect(v)
+		if !value.IsValid() || value.IsZero() {
+			return
+		}
+		if message := test(value, arg); message != "" {
+			errs.Add(name, message)
+		}
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// fieldName returns the name a field is known by in requests,
+// taken from its binding tags or its Go name otherwise.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"form", "json", "query", "param"} {
+		if name := strings.Split(field.Tag.Get(tag), ",")[0]; name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
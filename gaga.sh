@@ -22,6 +22,11 @@ serve() {
   "$(pwd)/build/$NAME"
 }
 
+assets() {
+  build "$1"
+  "$(pwd)/build/$NAME" assets
+}
+
 clean() {
   if [[ $1 == "cache" ]]
   then
@@ -47,6 +52,10 @@ help() {
   echo "            You may pass the name of the output executable of the build"
   echo "            process as an argument."
   echo "            [default=gaga]"
+  echo "  - assets: Fingerprints the static files and writes the asset manifest"
+  echo "            You may pass the name of the output executable of the build"
+  echo "            process as an argument."
+  echo "            [default=gaga]"
   echo "  - clean:  Clean the gaga cache and log files."
   echo "            You may specify which item to clean as below:"
   echo "                > logs: clean logs only"
@@ -67,6 +76,10 @@ case $ACTION in
     serve "$2"
     ;;
 
+  assets)
+    assets "$2"
+    ;;
+
   clean)
     clean "$2"
     ;;
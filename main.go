@@ -1,6 +1,9 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/mcfriend99/gaga/app"
 )
 
@@ -10,5 +13,17 @@ func main() {
 		RouteGenerator: Router,
 		Config:         config,
 	}
+
+	// `gaga assets` fingerprints the static files ahead of serving.
+	if len(os.Args) > 1 && os.Args[1] == "assets" {
+		if err := g.BuildAssets(); err != nil {
+			fmt.Println("Failed to fingerprint assets:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Successfully fingerprinted assets")
+		return
+	}
+
+	g.Use(app.AccessLog, app.Compress(config.SEO))
 	g.Serve()
 }